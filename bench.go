@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// benchPositions are searched by the bench command: the perft positions plus a few
+// ordinary middlegame positions
+var benchPositions = []string{
+	StartFEN,
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+	"r1bq1rk1/pp2bppp/2n2n2/3p4/3P4/2NB1N2/PP3PPP/R1BQ1RK1 w - - 0 10",
+	"2r2rk1/pp3ppp/2n1bn2/q2p4/3P4/P1NBPN2/1P3PPP/R2Q1RK1 w - - 0 14",
+}
+
+// runBench implements the bench command line mode:
+//
+//	chess bench [-depth n]
+//
+// It searches every bench position to a fixed depth with an empty transposition table
+// and prints the nodes needed for each depth, so changes to the search can be compared.
+func runBench(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(out)
+	depth := fs.Int("depth", 6, "search depth in plies")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// depth 0 would make the search unlimited
+	if *depth < 1 || *depth >= maxPly {
+		return fmt.Errorf("bench: invalid depth %d, must be 1 to %d", *depth, maxPly-1)
+	}
+
+	perDepth := make([]uint64, *depth+1)
+	var total uint64
+	start := time.Now()
+	for i, fen := range benchPositions {
+		pos, err := ParseFEN(fen)
+		if err != nil {
+			return err
+		}
+		transpositionTable.Clear()
+
+		var previous uint64
+		result := Search(context.Background(), &pos, SearchLimits{Depth: *depth}, func(r SearchResult) {
+			perDepth[r.Depth] += r.Nodes - previous
+			previous = r.Nodes
+		})
+		total += result.Nodes
+		fmt.Fprintf(out, "position %d: %-6s %10d nodes  %s\n", i+1, result.BestMove, result.Nodes, fen)
+	}
+	elapsed := time.Since(start)
+
+	fmt.Fprintln(out)
+	for d := 1; d <= *depth; d++ {
+		fmt.Fprintf(out, "depth %2d: %12d nodes\n", d, perDepth[d])
+	}
+	fmt.Fprintf(out, "Total: %d nodes, %v, %d nps\n", total, elapsed.Round(time.Millisecond), nodesPerSecond(total, elapsed))
+	return nil
+}
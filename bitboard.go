@@ -0,0 +1,251 @@
+package main
+
+import "math/bits"
+
+// A bitboard is a uint64 with one bit per square: bit 0 = A1, bit 7 = H1, ..., bit 63 = H8.
+
+// knightAttacks, kingAttacks and pawnAttacks[color] hold the attacked squares from every square
+var knightAttacks [64]uint64
+var kingAttacks [64]uint64
+var pawnAttacks [2][64]uint64
+
+// betweenBB[a][b] holds the squares strictly between a and b if they share a line, otherwise 0
+var betweenBB [64][64]uint64
+
+// lineBB[a][b] holds the full rank, file or diagonal through a and b, otherwise 0
+var lineBB [64][64]uint64
+
+// oppositeDirection maps an index into queenDirections to the index of the reverse direction
+var oppositeDirection = [8]int{1, 0, 3, 2, 6, 7, 4, 5}
+
+// magic holds the lookup data of one square for a slider:
+// attacks[((occupied & mask) * magic) >> shift] are the attacked squares
+type magic struct {
+	mask    uint64
+	magic   uint64
+	shift   uint
+	attacks []uint64
+}
+
+var rookMagics [64]magic
+var bishopMagics [64]magic
+
+// rookMagicNumbers and bishopMagicNumbers are multipliers found with searchMagic.
+// They are stored so startup does not have to repeat the search.
+var rookMagicNumbers = [64]uint64{
+	0x1080004008801020, 0x0840092002c03000, 0x1900200010400900, 0x0880100008000480,
+	0x4200100420080200, 0x8100020100080400, 0x0200040110886200, 0x0200008040220411,
+	0x0404800084400220, 0x0000401000402000, 0x0086001081220440, 0x0408800800100280,
+	0x000a001201040820, 0x8848800200840080, 0x4001000100040200, 0x0442000102105084,
+	0x9080010020804100, 0x0040404000201009, 0x0000808010002009, 0x2200090021d00100,
+	0x0008008008040080, 0x0004004002010040, 0x0011040008015042, 0x00000a0001768104,
+	0x0000800080204009, 0x2010004140002001, 0x9800200280100080, 0x1000100080080080,
+	0x0050500500080100, 0x0000020080040080, 0x0c10010400420810, 0x1040008200005104,
+	0x01808240088004a0, 0x0882804004802000, 0x0880402001001100, 0x2000210409001000,
+	0x2000480131001500, 0x0000800400800200, 0x000002380c001003, 0x4600084882000431,
+	0x0080002000504000, 0x0300500020004002, 0x0040408200220011, 0x0010040008004040,
+	0x0000080004008080, 0x0010040002008080, 0x2012004881020004, 0x8300842444820011,
+	0x0088403882010200, 0x0820400080210100, 0x0110910040a00300, 0x0801100280080480,
+	0x0242009008200600, 0x1002000489500200, 0x0040800200010080, 0x0091800041000080,
+	0x0000209300488001, 0x04c1002414824001, 0x020020000b001041, 0x7000100004200901,
+	0x8002002004100802, 0x30010002084c0007, 0x0888221800813004, 0x4000002840840112,
+}
+var bishopMagicNumbers = [64]uint64{
+	0x20c0090901061081, 0x0024040094030104, 0x8210810200290200, 0x0011040484620000,
+	0x0081104002221000, 0x0009012011001350, 0x0081010802400380, 0x0000420210010408,
+	0x0008105002280050, 0x0001028484040044, 0x2a00880810408804, 0x7020022282000100,
+	0x0084040420100a50, 0x000401010840e000, 0x2020020210420888, 0x0008084202012010,
+	0x2010400810018800, 0x0445122008020840, 0x0804100808002008, 0x0008002104110100,
+	0x0061005820080800, 0x2001000200820100, 0x480c210084010800, 0x3004442500480420,
+	0x1010102240048100, 0x00182009084220a3, 0x8803090a10004205, 0x0208080040202020,
+	0x000c044084010040, 0x00a1010002004106, 0x6008210020640202, 0x1600902112860801,
+	0x00042008c1220200, 0x010c042002440140, 0x5022080200040820, 0x0402004042940100,
+	0x0860108400008020, 0x000c080022021000, 0x0264080652822100, 0x4005031221010401,
+	0x0004502410008400, 0x000500b010a20400, 0x0415094050080800, 0x080000201800a104,
+	0x4022a80304000110, 0x4012140802028020, 0x40200104010100a0, 0x12810806008b0c41,
+	0x0020441008080000, 0x2002120084045420, 0x0704020062080002, 0x0000001084040001,
+	0x0322200891240200, 0xf040200210024800, 0x0140824832008042, 0x000210020a004602,
+	0x0083042805141020, 0x002c12009a011000, 0x0041a00044140400, 0x00004004020a0202,
+	0x0000140010020210, 0x2864160811012200, 0x2060080841082a17, 0xa010041108003100,
+}
+
+// squareBB returns a bitboard with only the given square set
+func squareBB(sq int) uint64 {
+	return 1 << uint(sq)
+}
+
+// lsb returns the lowest set square of a non-empty bitboard
+func lsb(b uint64) int {
+	return bits.TrailingZeros64(b)
+}
+
+// msb returns the highest set square of a non-empty bitboard
+func msb(b uint64) int {
+	return 63 - bits.LeadingZeros64(b)
+}
+
+// popLSB clears the lowest set square and returns it
+func popLSB(b *uint64) int {
+	sq := bits.TrailingZeros64(*b)
+	*b &= *b - 1
+	return sq
+}
+
+// popCount returns the number of set squares
+func popCount(b uint64) int {
+	return bits.OnesCount64(b)
+}
+
+// rookAttacks returns the squares a rook on sq attacks given the occupied squares
+func rookAttacks(sq int, occupied uint64) uint64 {
+	m := &rookMagics[sq]
+	return m.attacks[((occupied&m.mask)*m.magic)>>m.shift]
+}
+
+// bishopAttacks returns the squares a bishop on sq attacks given the occupied squares
+func bishopAttacks(sq int, occupied uint64) uint64 {
+	m := &bishopMagics[sq]
+	return m.attacks[((occupied&m.mask)*m.magic)>>m.shift]
+}
+
+// queenAttacks returns the squares a queen on sq attacks given the occupied squares
+func queenAttacks(sq int, occupied uint64) uint64 {
+	return rookAttacks(sq, occupied) | bishopAttacks(sq, occupied)
+}
+
+// slidingAttacks walks the SliderRays for the directions first..last-1 and stops each ray
+// at the first occupied square. It is slow and only used to build the magic tables.
+func slidingAttacks(sq int, occupied uint64, first, last int) uint64 {
+	var attacks uint64
+	for d := first; d < last; d++ {
+		for _, to := range SliderRays[sq][d] {
+			attacks |= squareBB(to)
+			if occupied&squareBB(to) != 0 {
+				break
+			}
+		}
+	}
+	return attacks
+}
+
+// initBitboards builds the attack bitboards from the target tables, so initMoveTargets must run first
+func initBitboards() {
+	for sq := 0; sq < 64; sq++ {
+		for _, to := range KnightTargets[sq] {
+			knightAttacks[sq] |= squareBB(to)
+		}
+		for _, to := range KingTargets[sq] {
+			kingAttacks[sq] |= squareBB(to)
+		}
+		for _, to := range PawnTargetsWhite[sq] {
+			if squareFile[to] != squareFile[sq] {
+				pawnAttacks[White][sq] |= squareBB(to)
+			}
+		}
+		for _, to := range PawnTargetsBlack[sq] {
+			if squareFile[to] != squareFile[sq] {
+				pawnAttacks[Black][sq] |= squareBB(to)
+			}
+		}
+	}
+
+	for a := 0; a < 64; a++ {
+		for d := range queenDirections {
+			ray := SliderRays[a][d]
+			var between uint64
+			for _, b := range ray {
+				betweenBB[a][b] = between
+				between |= squareBB(b)
+			}
+			line := squareBB(a) | between
+			for _, b := range SliderRays[a][oppositeDirection[d]] {
+				line |= squareBB(b)
+			}
+			for _, b := range ray {
+				lineBB[a][b] = line
+			}
+		}
+	}
+
+	rng := uint64(0x9e3779b97f4a7c15)
+	for sq := 0; sq < 64; sq++ {
+		initMagic(&rookMagics[sq], sq, 0, 4, rookMagicNumbers[sq], &rng)
+		initMagic(&bishopMagics[sq], sq, 4, 8, bishopMagicNumbers[sq], &rng)
+	}
+}
+
+// initMagic fills the attack table for the slider directions first..last-1 on sq.
+// It uses the stored multiplier and only searches a new one if that does not fit.
+func initMagic(m *magic, sq int, first, last int, stored uint64, rng *uint64) {
+	// the last square of each ray does not change the attacks, so it is left out of the mask
+	m.mask = 0
+	for d := first; d < last; d++ {
+		ray := SliderRays[sq][d]
+		for i := 0; i+1 < len(ray); i++ {
+			m.mask |= squareBB(ray[i])
+		}
+	}
+	n := popCount(m.mask)
+	m.shift = uint(64 - n)
+
+	// enumerate all subsets of the mask (Carry-Rippler) with their attacks
+	size := 1 << uint(n)
+	occupancies := make([]uint64, size)
+	reference := make([]uint64, size)
+	var sub uint64
+	for i := 0; i < size; i++ {
+		occupancies[i] = sub
+		reference[i] = slidingAttacks(sq, sub, first, last)
+		sub = (sub - m.mask) & m.mask
+	}
+
+	m.attacks = make([]uint64, size)
+	used := make([]int, size)
+	m.magic = stored
+	if !fillMagic(m, occupancies, reference, used, 1) {
+		searchMagic(m, occupancies, reference, used, rng)
+	}
+}
+
+// searchMagic tries sparse random multipliers until one maps every occupancy without a
+// harmful collision. The generator uses a fixed seed, so it always finds the same numbers.
+func searchMagic(m *magic, occupancies, reference []uint64, used []int, rng *uint64) {
+	for attempt := 2; ; attempt++ {
+		m.magic = sparseRandom(rng)
+		if popCount((m.mask*m.magic)>>56) < 6 {
+			continue
+		}
+		if fillMagic(m, occupancies, reference, used, attempt) {
+			return
+		}
+	}
+}
+
+// fillMagic writes the attack table for m.magic and reports whether it is free of collisions.
+// used[idx] holds the attempt that last wrote attacks[idx], so the table
+// does not need to be cleared between attempts.
+func fillMagic(m *magic, occupancies, reference []uint64, used []int, attempt int) bool {
+	for i := range occupancies {
+		idx := (occupancies[i] * m.magic) >> m.shift
+		if used[idx] != attempt {
+			used[idx] = attempt
+			m.attacks[idx] = reference[i]
+		} else if m.attacks[idx] != reference[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sparseRandom returns a random number with few bits set, a good candidate for a magic
+func sparseRandom(state *uint64) uint64 {
+	return xorshift(state) & xorshift(state) & xorshift(state)
+}
+
+// xorshift is a small xorshift64* pseudo random generator
+func xorshift(state *uint64) uint64 {
+	*state ^= *state >> 12
+	*state ^= *state << 25
+	*state ^= *state >> 27
+	return *state * 2685821657736338717
+}
@@ -1,6 +1,12 @@
 package main
 
-import "fmt"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
 
 const (
 	Pawn = iota
@@ -189,27 +195,56 @@ var squareRank = [...]int{
 	7, 7, 7, 7, 7, 7, 7, 7,
 }
 
-// squareScoreTable holds for each color and piece type a precomputed score for every square.
+// squareScoreOpening and squareScoreEndgame hold for each color and piece type a precomputed
+// middlegame and endgame score (material plus position) for every square.
 // index 0 = White, 1 = Black; piece index = Pawn..King, Empty
-var squareScoreTable [2][7][64]int
-
-// kingOpeningScore and kingEndgameScore per color
-var kingOpeningScore [2][64]int
-var kingEndgameScore [2][64]int
+var squareScoreOpening [2][7][64]int
+var squareScoreEndgame [2][7][64]int
 
 // flipSquare maps a square to the vertically flipped square (same file, mirrored rank).
 // e.g. A1 -> A8, B2 -> B7, ...
 var flipSquare [64]int
 
-// current board arrays (mutable game state)
-var boardPieces [64]int // piece type on each square (Pawn..King, Empty)
-var boardColors [64]int // color on each square (White, Black, Empty)
+// castling rights bit flags stored in Position.castling
+const (
+	castleWhiteKingSide = 1 << iota
+	castleWhiteQueenSide
+	castleBlackKingSide
+	castleBlackQueenSide
+)
 
-// global half-move ply counter
-var hply int
+// castleAll is the set of castling rights at game start
+const castleAll = castleWhiteKingSide | castleWhiteQueenSide | castleBlackKingSide | castleBlackQueenSide
+
+// noSquare marks an unset square, e.g. when no en-passant capture is possible
+const noSquare = -1
+
+// Position holds the complete state of a game: the board, the side to move,
+// castling rights, the en-passant square and the move counters.
+// It is a plain value, so a copy can be changed without touching the original;
+// only the undo stack is shared, use Clone before making moves on a copy.
+type Position struct {
+	pieces [64]int // piece type on each square (Pawn..King, Empty)
+	colors [64]int // color on each square (White, Black, Empty)
+
+	pieceBB [2][6]uint64 // bitboard per color and piece type (Pawn..King)
+	colorBB [2]uint64    // bitboard of all pieces per color
+
+	side           int // side to move (White, Black)
+	castling       int // castling rights (castleWhiteKingSide | ...)
+	epSquare       int // square a pawn may capture en passant onto, or noSquare
+	halfmoveClock  int // half-moves since the last capture or pawn move
+	fullmoveNumber int // starts at 1 and is incremented after Black's move
+
+	hash     uint64     // Zobrist key, updated incrementally by MakeMove
+	pawnHash uint64     // Zobrist key of the pawns only, for the pawn hash table
+	phase    int        // game phase counter from phaseWeight, updated by putPiece and removePiece
+	hply     int        // half-move ply counter, incremented by MakeMove
+	undo     []undoInfo // state needed by UnmakeMove, one entry per move made
+}
 
-// initSquareScoreTable fills squareScoreTable and kingOpeningScore/kingEndgameScore
-// by combining material value and positional tables defined in eval.go.
+// initSquareScoreTable fills squareScoreOpening and squareScoreEndgame
+// by combining material values and positional tables defined in eval.go.
 // Black tables are computed by flipping the positional tables via flipSquare.
 func initSquareScoreTable() {
 	// build flip table
@@ -219,46 +254,64 @@ func initSquareScoreTable() {
 		flipSquare[sq] = file + (7-rank)*8
 	}
 
-	// initialize move targets for all pieces
+	// initialize move targets and attack bitboards for all pieces
 	initMoveTargets()
-
-	for sq := 0; sq < 64; sq++ {
-		// White: use positional tables as-is
-		squareScoreTable[White][Pawn][sq] = pieceValues[Pawn] + PawnScore[sq]
-		squareScoreTable[White][Knight][sq] = pieceValues[Knight] + KnightScore[sq]
-		squareScoreTable[White][Bishop][sq] = pieceValues[Bishop] + BishopScore[sq]
-		squareScoreTable[White][Rook][sq] = pieceValues[Rook] + RookScore[sq]
-		squareScoreTable[White][Queen][sq] = pieceValues[Queen] + QueenScore[sq]
-		squareScoreTable[White][Empty][sq] = pieceValues[Empty]
-		kingOpeningScore[White][sq] = pieceValues[King] + KingScore[sq]
-		kingEndgameScore[White][sq] = pieceValues[King] + KingEndgameScore[sq]
-
-		// Black: mirror positional tables via flipSquare
-		fs := flipSquare[sq]
-		squareScoreTable[Black][Pawn][sq] = pieceValues[Pawn] + PawnScore[fs]
-		squareScoreTable[Black][Knight][sq] = pieceValues[Knight] + KnightScore[fs]
-		squareScoreTable[Black][Bishop][sq] = pieceValues[Bishop] + BishopScore[fs]
-		squareScoreTable[Black][Rook][sq] = pieceValues[Rook] + RookScore[fs]
-		squareScoreTable[Black][Queen][sq] = pieceValues[Queen] + QueenScore[fs]
-		squareScoreTable[Black][Empty][sq] = pieceValues[Empty]
-		kingOpeningScore[Black][sq] = pieceValues[King] + KingScore[fs]
-		kingEndgameScore[Black][sq] = pieceValues[King] + KingEndgameScore[fs]
+	initBitboards()
+	initCastlingMask()
+	initZobrist()
+	initPawnMasks()
+
+	openingTables := [6]*[64]int{&PawnScore, &KnightScore, &BishopScore, &RookScore, &QueenScore, &KingScore}
+	endgameTables := [6]*[64]int{&PawnEndgameScore, &KnightEndgameScore, &BishopEndgameScore,
+		&RookEndgameScore, &QueenEndgameScore, &KingEndgameScore}
+	for piece := Pawn; piece <= King; piece++ {
+		for sq := 0; sq < 64; sq++ {
+			// White: use positional tables as-is
+			squareScoreOpening[White][piece][sq] = materialOpening[piece] + openingTables[piece][sq]
+			squareScoreEndgame[White][piece][sq] = materialEndgame[piece] + endgameTables[piece][sq]
+
+			// Black: mirror positional tables via flipSquare
+			fs := flipSquare[sq]
+			squareScoreOpening[Black][piece][sq] = materialOpening[piece] + openingTables[piece][fs]
+			squareScoreEndgame[Black][piece][sq] = materialEndgame[piece] + endgameTables[piece][fs]
+		}
 	}
 }
 
-// initBoard initializes boardPieces and boardColors from the starting tables
-// and resets the half-move ply counter.
-func initBoard() {
+// NewPosition returns a Position set up with the standard starting position.
+func NewPosition() Position {
+	var p Position
+	p.initBoard()
+	return p
+}
+
+// initBoard sets up the starting position from initPieces/initColors
+// and resets side to move, castling rights, en passant and the move counters.
+func (p *Position) initBoard() {
+	p.clearBoard()
 	for i := 0; i < 64; i++ {
-		boardPieces[i] = initPieces[i]
-		boardColors[i] = initColors[i]
+		if initPieces[i] != Empty {
+			p.putPiece(i, initPieces[i], initColors[i])
+		}
 	}
-	hply = 0
+	p.side = White
+	p.castling = castleAll
+	p.epSquare = noSquare
+	p.halfmoveClock = 0
+	p.fullmoveNumber = 1
+	p.hply = 0
+	p.undo = p.undo[:0]
+	p.hash = p.ComputeHash()
 }
 
-// printBoard prints a simple ASCII representation of the current board.
+// printBoard prints a simple ASCII representation of the position.
 // White pieces are uppercase, Black pieces lowercase, empty squares shown as '.'.
-func printBoard() {
+func (p *Position) printBoard() {
+	p.writeBoard(os.Stdout, false)
+}
+
+// writeBoard draws the board to w, from Black's side when flipped
+func (p *Position) writeBoard(w io.Writer, flipped bool) {
 	whiteChar := map[int]byte{
 		Pawn: 'P', Knight: 'N', Bishop: 'B', Rook: 'R', Queen: 'Q', King: 'K', Empty: '.',
 	}
@@ -266,64 +319,101 @@ func printBoard() {
 		Pawn: 'p', Knight: 'n', Bishop: 'b', Rook: 'r', Queen: 'q', King: 'k', Empty: '.',
 	}
 
-	for r := 7; r >= 0; r-- {
-		fmt.Printf("%d ", r+1)
-		for f := 0; f < 8; f++ {
-			idx := r*8 + f
-			col := boardColors[idx]
-			p := boardPieces[idx]
-			if col == White {
-				fmt.Printf("%c ", whiteChar[p])
-			} else if col == Black {
-				fmt.Printf("%c ", blackChar[p])
-			} else {
-				fmt.Printf(". ")
+	writeGrid(w, flipped, 1, func(idx int) string {
+		col := p.colors[idx]
+		pc := p.pieces[idx]
+		if col == White {
+			return string(whiteChar[pc])
+		} else if col == Black {
+			return string(blackChar[pc])
+		}
+		return "."
+	})
+}
+
+// writeGrid draws the board grid with rank numbers and file letters to w, filling every
+// square with the text of cell right aligned to width characters
+func writeGrid(w io.Writer, flipped bool, width int, cell func(sq int) string) {
+	for i := 0; i < 8; i++ {
+		r := 7 - i
+		if flipped {
+			r = i
+		}
+		fmt.Fprintf(w, "%d ", r+1)
+		for j := 0; j < 8; j++ {
+			f := j
+			if flipped {
+				f = 7 - j
 			}
+			fmt.Fprintf(w, "%*s ", width, cell(r*8+f))
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
+	}
+
+	files := "abcdefgh"
+	if flipped {
+		files = "hgfedcba"
+	}
+	var footer strings.Builder
+	footer.WriteString(" ")
+	for _, c := range files {
+		fmt.Fprintf(&footer, " %*c", width, c)
 	}
-	fmt.Println("  a b c d e f g h")
+	fmt.Fprintln(w, footer.String())
 }
 
 // PrintMoveTargets zeigt alle Zielfelder für eine Position und Figur
 func PrintMoveTargets(square int, targets []int) {
+	writeMoveTargets(os.Stdout, square, targets)
+}
+
+// writeMoveTargets writes the target squares of the piece on square to w
+func writeMoveTargets(w io.Writer, square int, targets []int) {
 	file := square % 8
 	rank := square / 8
-	fmt.Printf("Square: %c%d (%d) -> Targets: ", 'a'+byte(file), rank+1, square)
+	fmt.Fprintf(w, "Square: %c%d (%d) -> Targets: ", 'a'+byte(file), rank+1, square)
 	for _, t := range targets {
-		fmt.Printf("%c%d ", 'a'+byte(t%8), t/8+1)
+		fmt.Fprintf(w, "%c%d ", 'a'+byte(t%8), t/8+1)
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 func main() {
 	// initialize precomputed square score tables
 	initSquareScoreTable()
 
-	// initialize board state
-	initBoard()
-
-	// show board
-	printBoard()
-
-	// example usage
-	fmt.Println("squareRank[10] =", squareRank[10])
-	fmt.Println("PawnScore[C3] =", PawnScore[10])
-	fmt.Println("KnightScore[C3] =", KnightScore[10])
-
-	// white pawn on C3
-	fmt.Println("white pawn score on C3 =", squareScoreTable[White][Pawn][10])
-	// black pawn on C3 (uses flipped positional table)
-	fmt.Println("black pawn score on C3 =", squareScoreTable[Black][Pawn][10])
+	// command line modes
+	if len(os.Args) > 1 {
+		modes := map[string]func([]string, io.Writer) error{
+			"perft": runPerft,
+			"bench": runBench,
+			"play":  runPlay,
+			"eval":  runEval,
+		}
+		if run, ok := modes[os.Args[1]]; ok {
+			if err := run(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
 
-	// king opening vs endgame on E4 (square index 28)
-	fmt.Println("kingOpeningScore white E4 =", kingOpeningScore[White][28])
-	fmt.Println("kingEndgameScore black E4 =", kingEndgameScore[Black][28])
+	// long "position ... moves" lines need a bigger buffer
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
 
-	fmt.Print("Debug: Knight from D4 can move to: ")
-	for _, t := range KnightTargets[D4] {
-		fmt.Print(IndexToAlgebraic(t), " ")
+	// the first command decides the protocol: xboard for CECP, anything else is UCI
+	for scanner.Scan() {
+		first := strings.TrimSpace(scanner.Text())
+		if first == "" {
+			continue
+		}
+		if first == "xboard" {
+			runXBoard(scanner, os.Stdout)
+		} else {
+			runUCI(scanner, os.Stdout, first)
+		}
+		return
 	}
-	fmt.Println()
-	fmt.Printf("Debug: Square E4 = %d\n", E4)
 }
@@ -0,0 +1,6 @@
+//go:build debug
+
+package main
+
+// debugChecks enables expensive consistency checks, build with -tags debug
+const debugChecks = true
@@ -21,6 +21,26 @@ var PawnScore = [...]int{
 	0, 0, 0, 0, 0, 0, 0, 0,
 }
 
+// PawnEndgameScore: in the endgame only advancing counts
+var PawnEndgameScore = [...]int{
+	// Rank 1
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 2
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 3
+	10, 10, 10, 10, 10, 10, 10, 10,
+	// Rank 4
+	20, 20, 20, 20, 20, 20, 20, 20,
+	// Rank 5
+	35, 35, 35, 35, 35, 35, 35, 35,
+	// Rank 6
+	55, 55, 55, 55, 55, 55, 55, 55,
+	// Rank 7
+	90, 90, 90, 90, 90, 90, 90, 90,
+	// Rank 8
+	0, 0, 0, 0, 0, 0, 0, 0,
+}
+
 // KnightScore: typical knight-centralization table (negative on edges/corners)
 var KnightScore = [...]int{
 	// Rank 1
@@ -41,32 +61,72 @@ var KnightScore = [...]int{
 	-50, -40, -30, -30, -30, -30, -40, -50,
 }
 
+// KnightEndgameScore: knights still belong in the centre, edges cost less than in the middlegame
+var KnightEndgameScore = [...]int{
+	// Rank 1
+	-40, -30, -20, -20, -20, -20, -30, -40,
+	// Rank 2
+	-30, -15, -5, 0, 0, -5, -15, -30,
+	// Rank 3
+	-20, -5, 5, 10, 10, 5, -5, -20,
+	// Rank 4
+	-20, 0, 10, 15, 15, 10, 0, -20,
+	// Rank 5
+	-20, 0, 10, 15, 15, 10, 0, -20,
+	// Rank 6
+	-20, -5, 5, 10, 10, 5, -5, -20,
+	// Rank 7
+	-30, -15, -5, 0, 0, -5, -15, -30,
+	// Rank 8
+	-40, -30, -20, -20, -20, -20, -30, -40,
+}
+
 // BishopScore: favors long diagonals and center
 var BishopScore = [...]int{
 	// Rank 1
 	-20, -10, -10, -10, -10, -10, -10, -20,
 	// Rank 2
-	-10, 0, 0, 0, 0, 0, 0, -10,
+	-10, 5, 0, 0, 0, 0, 5, -10,
 	// Rank 3
-	-10, 0, 5, 10, 10, 5, 0, -10,
+	-10, 10, 10, 10, 10, 10, 10, -10,
 	// Rank 4
-	-10, 5, 5, 10, 10, 5, 5, -10,
-	// Rank 5
 	-10, 0, 10, 10, 10, 10, 0, -10,
+	// Rank 5
+	-10, 5, 5, 10, 10, 5, 5, -10,
 	// Rank 6
-	-10, 10, 10, 10, 10, 10, 10, -10,
+	-10, 0, 5, 10, 10, 5, 0, -10,
 	// Rank 7
-	-10, 5, 0, 0, 0, 0, 5, -10,
+	-10, 0, 0, 0, 0, 0, 0, -10,
 	// Rank 8
 	-20, -10, -10, -10, -10, -10, -10, -20,
 }
 
-// RookScore: favors open files and ranks closer to opponent
+// BishopEndgameScore: mild preference for the centre
+var BishopEndgameScore = [...]int{
+	// Rank 1
+	-15, -10, -10, -5, -5, -10, -10, -15,
+	// Rank 2
+	-10, -5, 0, 0, 0, 0, -5, -10,
+	// Rank 3
+	-10, 0, 5, 5, 5, 5, 0, -10,
+	// Rank 4
+	-5, 0, 5, 10, 10, 5, 0, -5,
+	// Rank 5
+	-5, 0, 5, 10, 10, 5, 0, -5,
+	// Rank 6
+	-10, 0, 5, 5, 5, 5, 0, -10,
+	// Rank 7
+	-10, -5, 0, 0, 0, 0, -5, -10,
+	// Rank 8
+	-15, -10, -10, -5, -5, -10, -10, -15,
+}
+
+// RookScore: favors the centre files of the back rank and the 7th rank
 var RookScore = [...]int{
 	// Rank 1
 	0, 0, 0, 5, 5, 0, 0, 0,
 	// Rank 2
-	5, 10, 10, 10, 10, 10, 10, 5,
+	-5, 0, 0, 0, 0, 0, 0, -5,
 	// Rank 3
 	-5, 0, 0, 0, 0, 0, 0, -5,
 	// Rank 4
@@ -76,23 +136,43 @@ var RookScore = [...]int{
 	// Rank 6
 	-5, 0, 0, 0, 0, 0, 0, -5,
 	// Rank 7
-	-5, 0, 0, 0, 0, 0, 0, -5,
+	5, 10, 10, 10, 10, 10, 10, 5,
 	// Rank 8
 	0, 0, 0, 0, 0, 0, 0, 0,
 }
 
+// RookEndgameScore: rooks are good anywhere, best on the 7th rank
+var RookEndgameScore = [...]int{
+	// Rank 1
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 2
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 3
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 4
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 5
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 6
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 7
+	10, 10, 10, 10, 10, 10, 10, 10,
+	// Rank 8
+	5, 5, 5, 5, 5, 5, 5, 5,
+}
+
 // QueenScore: combines mobility and centralization
 var QueenScore = [...]int{
 	// Rank 1
 	-20, -10, -10, -5, -5, -10, -10, -20,
 	// Rank 2
-	-10, 0, 0, 0, 0, 0, 0, -10,
+	-10, 0, 5, 0, 0, 0, 0, -10,
 	// Rank 3
-	-10, 0, 5, 5, 5, 5, 0, -10,
+	-10, 5, 5, 5, 5, 5, 0, -10,
 	// Rank 4
-	-5, 0, 5, 5, 5, 5, 0, -5,
-	// Rank 5
 	0, 0, 5, 5, 5, 5, 0, -5,
+	// Rank 5
+	-5, 0, 5, 5, 5, 5, 0, -5,
 	// Rank 6
 	-10, 0, 5, 5, 5, 5, 0, -10,
 	// Rank 7
@@ -101,24 +181,44 @@ var QueenScore = [...]int{
 	-20, -10, -10, -5, -5, -10, -10, -20,
 }
 
-// KingScore: simple middlegame table (prefer safety; encourage centralization slightly in endgame)
-var KingScore = [...]int{
+// QueenEndgameScore: a central queen reaches the whole board
+var QueenEndgameScore = [...]int{
 	// Rank 1
-	-30, -40, -40, -50, -50, -40, -40, -30,
+	-30, -20, -10, -10, -10, -10, -20, -30,
 	// Rank 2
-	-30, -40, -40, -50, -50, -40, -40, -30,
+	-20, -10, 0, 0, 0, 0, -10, -20,
 	// Rank 3
-	-30, -40, -40, -50, -50, -40, -40, -30,
+	-10, 0, 10, 10, 10, 10, 0, -10,
 	// Rank 4
-	-30, -40, -40, -50, -50, -40, -40, -30,
+	-10, 0, 10, 20, 20, 10, 0, -10,
 	// Rank 5
-	-20, -30, -30, -40, -40, -30, -30, -20,
+	-10, 0, 10, 20, 20, 10, 0, -10,
 	// Rank 6
-	-10, -20, -20, -20, -20, -20, -20, -10,
+	-10, 0, 10, 10, 10, 10, 0, -10,
 	// Rank 7
-	20, 20, 0, 0, 0, 0, 20, 20,
+	-20, -10, 0, 0, 0, 0, -10, -20,
 	// Rank 8
+	-30, -20, -10, -10, -10, -10, -20, -30,
+}
+
+// KingScore: simple middlegame table (prefer safety behind the own pawns near the back rank)
+var KingScore = [...]int{
+	// Rank 1
 	20, 30, 10, 0, 0, 10, 30, 20,
+	// Rank 2
+	20, 20, 0, 0, 0, 0, 20, 20,
+	// Rank 3
+	-10, -20, -20, -20, -20, -20, -20, -10,
+	// Rank 4
+	-20, -30, -30, -40, -40, -30, -30, -20,
+	// Rank 5
+	-30, -40, -40, -50, -50, -40, -40, -30,
+	// Rank 6
+	-30, -40, -40, -50, -50, -40, -40, -30,
+	// Rank 7
+	-30, -40, -40, -50, -50, -40, -40, -30,
+	// Rank 8
+	-30, -40, -40, -50, -50, -40, -40, -30,
 }
 
 // KingEndgameScore: king positional table for the endgame where the king is stronger in the centre
@@ -150,15 +250,96 @@ var PassedPawnScore = [...]int{
 	// Rank 2 (A2..H2)
 	0, 0, 0, 0, 0, 0, 0, 0,
 	// Rank 3 (A3..H3)
-	60, 60, 60, 60, 60, 60, 60, 60,
+	8, 8, 8, 8, 8, 8, 8, 8,
 	// Rank 4 (A4..H4)
-	30, 30, 30, 30, 30, 30, 30, 30,
+	8, 8, 8, 8, 8, 8, 8, 8,
 	// Rank 5 (A5..H5)
 	15, 15, 15, 15, 15, 15, 15, 15,
 	// Rank 6 (A6..H6)
-	8, 8, 8, 8, 8, 8, 8, 8,
+	30, 30, 30, 30, 30, 30, 30, 30,
 	// Rank 7 (A7..H7) - very strong
-	8, 8, 8, 8, 8, 8, 8, 8,
+	60, 60, 60, 60, 60, 60, 60, 60,
 	// Rank 8 (A8..H8) - promotion square (handled separately)
 	0, 0, 0, 0, 0, 0, 0, 0,
 }
+
+// PassedPawnEndgameScore: passed pawns decide endgames, so they are worth about twice as much there
+var PassedPawnEndgameScore = [...]int{
+	// Rank 1
+	0, 0, 0, 0, 0, 0, 0, 0,
+	// Rank 2
+	5, 5, 5, 5, 5, 5, 5, 5,
+	// Rank 3
+	15, 15, 15, 15, 15, 15, 15, 15,
+	// Rank 4
+	25, 25, 25, 25, 25, 25, 25, 25,
+	// Rank 5
+	45, 45, 45, 45, 45, 45, 45, 45,
+	// Rank 6
+	75, 75, 75, 75, 75, 75, 75, 75,
+	// Rank 7
+	120, 120, 120, 120, 120, 120, 120, 120,
+	// Rank 8
+	0, 0, 0, 0, 0, 0, 0, 0,
+}
+
+// materialOpening and materialEndgame are the piece values the evaluation uses in the
+// middlegame and the endgame, indexed like pieceValues. Pawns and rooks gain in the endgame.
+var materialOpening = [...]int{100, 320, 330, 480, 950, 0, 0}
+var materialEndgame = [...]int{120, 290, 320, 540, 980, 0, 0}
+
+// phaseWeight is what a piece adds to the game phase. All pieces of the starting position
+// add up to openingPhase; the phase falls towards 0 as pieces are captured.
+var phaseWeight = [...]int{0, 1, 1, 2, 4, 0, 0}
+
+// openingPhase is the game phase at the start of the game
+const openingPhase = 24
+
+// Evaluate returns the static score of the position in centipawns from the side to move's
+// point of view. It sums the opening and the endgame square scores over the board and adds
+// the pawn structure, king safety, mobility and piece activity terms, then blends the opening
+// and endgame totals by game phase.
+func (p *Position) Evaluate() int {
+	var opening, endgame [2]int
+	for color := White; color <= Black; color++ {
+		for piece := Pawn; piece <= King; piece++ {
+			for b := p.pieceBB[color][piece]; b != 0; {
+				sq := popLSB(&b)
+				opening[color] += squareScoreOpening[color][piece][sq]
+				endgame[color] += squareScoreEndgame[color][piece][sq]
+			}
+		}
+	}
+
+	pawnOpening, pawnEndgame := p.pawnStructure()
+	opening[White] += pawnOpening
+	endgame[White] += pawnEndgame
+
+	for color := White; color <= Black; color++ {
+		for _, term := range [...][2]int{p.kingSafety(color), p.pieceMobility(color), p.pieceActivity(color)} {
+			opening[color] += term[0]
+			endgame[color] += term[1]
+		}
+	}
+
+	phase := p.gamePhase()
+	us, them := p.side, p.side^1
+	return ((opening[us]-opening[them])*phase + (endgame[us]-endgame[them])*(openingPhase-phase)) / openingPhase
+}
+
+// gamePhase returns the game phase from openingPhase at the start down to 0 with only
+// pawns and kings left. Promotions can raise the phase counter beyond openingPhase.
+func (p *Position) gamePhase() int {
+	return minInt(p.phase, openingPhase)
+}
+
+// checkPhase panics if the incremental phase counter does not match the pieces on the board
+func (p *Position) checkPhase(where string) {
+	phase := 0
+	for piece := Knight; piece <= Queen; piece++ {
+		phase += phaseWeight[piece] * popCount(p.pieceBB[White][piece]|p.pieceBB[Black][piece])
+	}
+	if phase != p.phase {
+		panic(where + ": incremental phase does not match the pieces for " + p.FEN())
+	}
+}
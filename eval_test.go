@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// mirroredFEN swaps the colors of a FEN position: the board is flipped vertically, the
+// pieces, castling rights and side to move change color and the en-passant square moves along
+func mirroredFEN(fen string) string {
+	fields := strings.Fields(fen)
+	swapCase := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z':
+				return r - 'A' + 'a'
+			}
+			return r
+		}, s)
+	}
+
+	ranks := strings.Split(fields[0], "/")
+	for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+	fields[0] = swapCase(strings.Join(ranks, "/"))
+	if fields[1] == "w" {
+		fields[1] = "b"
+	} else {
+		fields[1] = "w"
+	}
+	if fields[2] != "-" {
+		fields[2] = swapCase(fields[2])
+	}
+	if ep := fields[3]; ep != "-" {
+		fields[3] = ep[:1] + string('1'+'8'-ep[1])
+	}
+	return strings.Join(fields, " ")
+}
+
+func TestEvaluateMirror(t *testing.T) {
+	fens := append([]string{
+		"r1bqk2r/Bpp2ppp/1p6/8/8/8/PPP2PPP/R2QKBNR w KQkq - 0 1",
+		"r2qkb1r/ppp2ppp/8/3Np3/4P3/8/PPP2PPP/R2QKBR1 w Qkq - 0 1",
+		"6k1/R7/8/8/8/8/5PPP/6K1 w - - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+		"8/5k2/3p4/2pP4/2P5/5K2/8/8 b - - 0 1",
+	}, benchPositions...)
+	for _, fen := range fens {
+		pos, err := ParseFEN(fen)
+		if err != nil {
+			t.Fatalf("%s: %v", fen, err)
+		}
+		mirror, err := ParseFEN(mirroredFEN(fen))
+		if err != nil {
+			t.Fatalf("%s: %v", mirroredFEN(fen), err)
+		}
+		// the score is from the side to move's point of view, so it must not change
+		if a, b := pos.Evaluate(), mirror.Evaluate(); a != b {
+			t.Errorf("%s: evaluates to %d, mirrored to %d", fen, a, b)
+		}
+	}
+}
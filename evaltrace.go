@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// evalTerm is one row of the evaluation trace
+type evalTerm struct {
+	name  string
+	score [2][2]int // per color, opening and endgame
+}
+
+// evalTrace breaks the static evaluation down into its terms. The terms add up to the
+// opening and endgame totals that Evaluate blends.
+func (p *Position) evalTrace() []evalTerm {
+	material := evalTerm{name: "Material"}
+	squares := make([]evalTerm, King+1) // the piece-square tables without material
+	for piece, name := range [...]string{"Pawn", "Knight", "Bishop", "Rook", "Queen", "King"} {
+		squares[piece].name = name + " PST"
+	}
+	for color := White; color <= Black; color++ {
+		for piece := Pawn; piece <= King; piece++ {
+			for b := p.pieceBB[color][piece]; b != 0; {
+				sq := popLSB(&b)
+				material.score[color][0] += materialOpening[piece]
+				material.score[color][1] += materialEndgame[piece]
+				squares[piece].score[color][0] += squareScoreOpening[color][piece][sq] - materialOpening[piece]
+				squares[piece].score[color][1] += squareScoreEndgame[color][piece][sq] - materialEndgame[piece]
+			}
+		}
+	}
+
+	terms := append([]evalTerm{material}, squares...)
+	structure := evalTerm{name: "Pawn structure"}
+	passed := evalTerm{name: "Passed pawns"}
+	safety := evalTerm{name: "King safety"}
+	mobility := evalTerm{name: "Mobility"}
+	activity := evalTerm{name: "Piece activity"}
+	for color := White; color <= Black; color++ {
+		structure.score[color], passed.score[color] = p.evaluatePawns(color)
+		safety.score[color] = p.kingSafety(color)
+		mobility.score[color] = p.pieceMobility(color)
+		activity.score[color] = p.pieceActivity(color)
+	}
+	return append(terms, structure, passed, safety, mobility, activity)
+}
+
+// writeEvalTrace writes the evaluation terms as a table in pawns, followed by a heatmap of
+// the piece-square scores. All scores are from White's point of view.
+func (p *Position) writeEvalTrace(w io.Writer) {
+	phase := p.gamePhase()
+	taper := func(opening, endgame int) float64 {
+		return float64(opening*phase+endgame*(openingPhase-phase)) / openingPhase / 100
+	}
+	pawns := func(score int) float64 {
+		return float64(score) / 100
+	}
+
+	fmt.Fprintln(w, "Term            |     White     |     Black     |     Total     | Tapered")
+	fmt.Fprintln(w, "                |    MG     EG  |    MG     EG  |    MG     EG  |")
+	fmt.Fprintln(w, "----------------+---------------+---------------+---------------+--------")
+	total := evalTerm{name: "Total"}
+	row := func(t evalTerm) {
+		opening := t.score[White][0] - t.score[Black][0]
+		endgame := t.score[White][1] - t.score[Black][1]
+		fmt.Fprintf(w, "%-15s | %6.2f %6.2f | %6.2f %6.2f | %6.2f %6.2f | %7.2f\n", t.name,
+			pawns(t.score[White][0]), pawns(t.score[White][1]),
+			pawns(t.score[Black][0]), pawns(t.score[Black][1]),
+			pawns(opening), pawns(endgame), taper(opening, endgame))
+	}
+	for _, t := range p.evalTrace() {
+		row(t)
+		for color := White; color <= Black; color++ {
+			total.score[color][0] += t.score[color][0]
+			total.score[color][1] += t.score[color][1]
+		}
+	}
+	fmt.Fprintln(w, "----------------+---------------+---------------+---------------+--------")
+	row(total)
+
+	score := p.Evaluate()
+	if p.side == Black {
+		score = -score
+	}
+	fmt.Fprintf(w, "\nPhase %d/%d, evaluation %+.2f (White's view)\n\n", phase, openingPhase, pawns(score))
+
+	fmt.Fprintln(w, "Piece-square scores in centipawns, tapered (White's view):")
+	writeGrid(w, false, 5, func(sq int) string {
+		piece, color := p.pieces[sq], p.colors[sq]
+		if piece == Empty {
+			return "."
+		}
+		opening := squareScoreOpening[color][piece][sq] - materialOpening[piece]
+		endgame := squareScoreEndgame[color][piece][sq] - materialEndgame[piece]
+		score := (opening*phase + endgame*(openingPhase-phase)) / openingPhase
+		letter := pieceLetters[piece]
+		if color == Black {
+			letter, score = letter+'a'-'A', -score
+		}
+		return fmt.Sprintf("%c%+d", letter, score)
+	})
+}
+
+// runEval implements the eval command line mode:
+//
+//	chess eval [FEN]
+//
+// It prints the evaluation trace of the position, the start position by default.
+func runEval(args []string, out io.Writer) error {
+	pos := NewPosition()
+	if len(args) > 0 {
+		var err error
+		if pos, err = ParseFEN(strings.Join(args, " ")); err != nil {
+			return err
+		}
+	}
+	pos.writeBoard(out, false)
+	fmt.Fprintln(out)
+	pos.writeEvalTrace(out)
+	return nil
+}
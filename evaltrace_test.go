@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalTraceAddsUp(t *testing.T) {
+	for _, fen := range benchPositions {
+		for _, f := range []string{fen, mirroredFEN(fen)} {
+			pos, err := ParseFEN(f)
+			if err != nil {
+				t.Fatalf("%s: %v", f, err)
+			}
+			var opening, endgame [2]int
+			for _, term := range pos.evalTrace() {
+				for color := White; color <= Black; color++ {
+					opening[color] += term.score[color][0]
+					endgame[color] += term.score[color][1]
+				}
+			}
+			us, them := pos.side, pos.side^1
+			phase := pos.gamePhase()
+			want := ((opening[us]-opening[them])*phase + (endgame[us]-endgame[them])*(openingPhase-phase)) / openingPhase
+			if got := pos.Evaluate(); got != want {
+				t.Errorf("%s: Evaluate = %d, the trace adds up to %d", f, got, want)
+			}
+		}
+	}
+}
+
+func TestRunEval(t *testing.T) {
+	var sb strings.Builder
+	if err := runEval([]string{"8/8/8/4k3/8/8/3QK3/8", "w", "-", "-", "0", "1"}, &sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Material", "King safety", "Total", "Phase 4/24"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("eval output lacks %q:\n%s", want, sb.String())
+		}
+	}
+	if err := runEval([]string{"not", "a", "fen"}, &sb); err == nil {
+		t.Error("no error for an invalid FEN")
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartFEN is the standard starting position in Forsyth–Edwards Notation.
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// pieceLetters maps piece types (Pawn..King) to their upper case FEN letter
+const pieceLetters = "PNBRQK"
+
+// ParseFEN builds a Position from a FEN string.
+// The halfmove clock and fullmove number may be omitted and default to 0 and 1.
+func ParseFEN(fen string) (Position, error) {
+	var p Position
+
+	fields := strings.Fields(fen)
+	if len(fields) != 4 && len(fields) != 6 {
+		return p, fmt.Errorf("fen: expected 6 fields, got %d", len(fields))
+	}
+
+	if err := p.parsePlacement(fields[0]); err != nil {
+		return p, err
+	}
+
+	switch fields[1] {
+	case "w":
+		p.side = White
+	case "b":
+		p.side = Black
+	default:
+		return p, fmt.Errorf("fen: invalid side to move %q", fields[1])
+	}
+	if p.InCheck(p.side ^ 1) {
+		return p, fmt.Errorf("fen: the side not to move is in check")
+	}
+
+	if fields[2] != "-" {
+		for _, c := range fields[2] {
+			var right int
+			switch c {
+			case 'K':
+				right = castleWhiteKingSide
+			case 'Q':
+				right = castleWhiteQueenSide
+			case 'k':
+				right = castleBlackKingSide
+			case 'q':
+				right = castleBlackQueenSide
+			default:
+				return p, fmt.Errorf("fen: invalid castling character %q", c)
+			}
+			if p.castling&right != 0 {
+				return p, fmt.Errorf("fen: duplicate castling character %q", c)
+			}
+			p.castling |= right
+		}
+	}
+	if err := p.checkCastlingRights(); err != nil {
+		return p, err
+	}
+
+	p.epSquare = noSquare
+	if fields[3] != "-" {
+		sq, err := AlgebraicToIndex(fields[3])
+		if err != nil {
+			return p, fmt.Errorf("fen: invalid en-passant square %q", fields[3])
+		}
+		if (p.side == White && squareRank[sq] != 5) || (p.side == Black && squareRank[sq] != 2) {
+			return p, fmt.Errorf("fen: en-passant square %s is not on the expected rank", fields[3])
+		}
+		pushed := sq - 8 // the square of the pawn that just made the double push
+		if p.side == Black {
+			pushed = sq + 8
+		}
+		if p.pieces[pushed] != Pawn || p.colors[pushed] != p.side^1 || p.pieces[sq] != Empty {
+			return p, fmt.Errorf("fen: no pawn can have passed en-passant square %s", fields[3])
+		}
+		// like MakeMove, only keep the square when a pawn can actually capture there,
+		// so the hash matches the one of the same position reached by moves
+		if pawnAttacks[p.side^1][sq]&p.pieceBB[p.side][Pawn] != 0 {
+			p.epSquare = sq
+		}
+	}
+
+	p.fullmoveNumber = 1
+	if len(fields) == 6 {
+		n, err := strconv.Atoi(fields[4])
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("fen: invalid halfmove clock %q", fields[4])
+		}
+		p.halfmoveClock = n
+
+		n, err = strconv.Atoi(fields[5])
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("fen: invalid fullmove number %q", fields[5])
+		}
+		p.fullmoveNumber = n
+	}
+
+	p.hash = p.ComputeHash()
+	return p, nil
+}
+
+// parsePlacement fills the board arrays from the piece placement field of a FEN.
+// Ranks are listed from 8 down to 1, so the first character belongs on A8.
+func (p *Position) parsePlacement(placement string) error {
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return fmt.Errorf("fen: expected 8 ranks, got %d", len(ranks))
+	}
+
+	p.clearBoard()
+
+	var kings [2]int
+	for i, row := range ranks {
+		rank := 7 - i
+		file := 0
+		for _, c := range row {
+			if c >= '1' && c <= '8' {
+				file += int(c - '0')
+				continue
+			}
+			idx := strings.IndexRune(pieceLetters, c)
+			color := White
+			if idx < 0 {
+				idx = strings.IndexRune(strings.ToLower(pieceLetters), c)
+				color = Black
+			}
+			if idx < 0 {
+				return fmt.Errorf("fen: unknown piece letter %q on rank %d", c, rank+1)
+			}
+			if file > 7 {
+				return fmt.Errorf("fen: rank %d has more than 8 squares", rank+1)
+			}
+			if idx == Pawn && (rank == 0 || rank == 7) {
+				return fmt.Errorf("fen: pawn on rank %d", rank+1)
+			}
+			if idx == King {
+				kings[color]++
+			}
+			p.putPiece(rank*8+file, idx, color)
+			file++
+		}
+		if file != 8 {
+			return fmt.Errorf("fen: rank %d has %d squares, expected 8", rank+1, file)
+		}
+	}
+
+	for color, name := range [...]string{"white", "black"} {
+		if kings[color] != 1 {
+			return fmt.Errorf("fen: expected one %s king, found %d", name, kings[color])
+		}
+	}
+	return nil
+}
+
+// checkCastlingRights verifies that king and rook stand on their home squares
+// for every castling right that is set.
+func (p *Position) checkCastlingRights() error {
+	rights := [...]struct {
+		right      int
+		color      int
+		king, rook Square
+	}{
+		{castleWhiteKingSide, White, E1, H1},
+		{castleWhiteQueenSide, White, E1, A1},
+		{castleBlackKingSide, Black, E8, H8},
+		{castleBlackQueenSide, Black, E8, A8},
+	}
+	for _, r := range rights {
+		if p.castling&r.right == 0 {
+			continue
+		}
+		if p.pieces[r.king] != King || p.colors[r.king] != r.color ||
+			p.pieces[r.rook] != Rook || p.colors[r.rook] != r.color {
+			return fmt.Errorf("fen: castling right without king on %s and rook on %s",
+				IndexToAlgebraic(int(r.king)), IndexToAlgebraic(int(r.rook)))
+		}
+	}
+	return nil
+}
+
+// FEN returns the position in Forsyth–Edwards Notation.
+func (p *Position) FEN() string {
+	var sb strings.Builder
+
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			sq := rank*8 + file
+			if p.pieces[sq] == Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			c := pieceLetters[p.pieces[sq]]
+			if p.colors[sq] == Black {
+				c += 'a' - 'A'
+			}
+			sb.WriteByte(c)
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+	}
+
+	if p.side == White {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+
+	if p.castling == 0 {
+		sb.WriteByte('-')
+	} else {
+		for i, c := range "KQkq" {
+			if p.castling&(1<<i) != 0 {
+				sb.WriteRune(c)
+			}
+		}
+	}
+
+	if p.epSquare == noSquare {
+		sb.WriteString(" -")
+	} else {
+		sb.WriteString(" " + IndexToAlgebraic(p.epSquare))
+	}
+
+	fmt.Fprintf(&sb, " %d %d", p.halfmoveClock, p.fullmoveNumber)
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b Kq e3 0 3",
+		"rnbqkbnr/pp1ppppp/8/2pP4/8/8/PPP1PPPP/RNBQKBNR w KQkq c6 0 2",
+		"4k3/8/8/8/8/8/8/4K3 b - - 99 120",
+	}
+	for _, fen := range fens {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			t.Errorf("ParseFEN(%q): %v", fen, err)
+			continue
+		}
+		if got := p.FEN(); got != fen {
+			t.Errorf("ParseFEN(%q).FEN() = %q", fen, got)
+		}
+		if p.hash != p.ComputeHash() {
+			t.Errorf("ParseFEN(%q): hash does not match the position", fen)
+		}
+	}
+}
+
+func TestParseFENDefaultCounters(t *testing.T) {
+	p, err := ParseFEN("4k3/8/8/8/8/8/8/4K3 w - -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.halfmoveClock != 0 || p.fullmoveNumber != 1 {
+		t.Errorf("got halfmove clock %d and fullmove number %d, want 0 and 1", p.halfmoveClock, p.fullmoveNumber)
+	}
+}
+
+func TestParseFENEnPassantMatchesMakeMove(t *testing.T) {
+	// no black pawn can capture on e3, so the square is dropped as MakeMove does
+	p, err := ParseFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(p.FEN())[3]; got != "-" {
+		t.Errorf("en-passant field = %q, want -", got)
+	}
+
+	q := NewPosition()
+	m, err := q.ParseMove("e2e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.MakeMove(m)
+	if p.hash != q.hash {
+		t.Errorf("hash %x differs from the hash %x after 1.e4", p.hash, q.hash)
+	}
+}
+
+func TestParseFENErrors(t *testing.T) {
+	tests := []struct {
+		fen, err string
+	}{
+		{"", "expected 6 fields"},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0", "expected 6 fields"},
+		{"4k3/8/8/8/8/8/4K3 w - - 0 1", "expected 8 ranks"},
+		{"4k3/8/8/8/8/8/8/4K3R w - - 0 1", "more than 8 squares"},
+		{"4k3/8/8/8/8/8/8/4K4 w - - 0 1", "has 9 squares"},
+		{"4k3/8/8/8/8/8/8/4K2 w - - 0 1", "has 7 squares"},
+		{"4k3/8/8/8/8/8/8/4X3 w - - 0 1", "unknown piece letter"},
+		{"4k2P/8/8/8/8/8/8/4K3 w - - 0 1", "pawn on rank 8"},
+		{"8/8/8/8/8/8/8/4K3 w - - 0 1", "expected one black king"},
+		{"4k3/8/8/8/8/8/8/3KK3 w - - 0 1", "expected one white king"},
+		{"4k3/8/8/8/8/8/8/4K3 x - - 0 1", "invalid side to move"},
+		{"4k3/8/8/8/8/8/8/4K3 w X - 0 1", "invalid castling character"},
+		{"4k3/8/8/8/8/8/8/4K2R w KK - 0 1", "duplicate castling character"},
+		{"4k3/8/8/8/8/8/8/4K3 w K - 0 1", "castling right without king on e1 and rook on h1"},
+		{"4k3/8/8/8/8/8/8/4K3 w - e9 0 1", "invalid en-passant square"},
+		{"4k3/8/8/3Pp3/8/8/8/4K3 w - e3 0 1", "not on the expected rank"},
+		{"4k3/8/8/3P4/8/8/8/4K3 w - e6 0 1", "no pawn can have passed en-passant square e6"},
+		{"4k3/8/8/8/8/8/8/4RK2 w - - 0 1", "side not to move is in check"},
+		{"4k3/8/8/8/8/8/8/4K3 w - - -1 1", "invalid halfmove clock"},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 0", "invalid fullmove number"},
+	}
+	for _, tt := range tests {
+		_, err := ParseFEN(tt.fen)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ParseFEN(%q) error = %v, want %q", tt.fen, err, tt.err)
+		}
+	}
+}
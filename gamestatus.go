@@ -0,0 +1,141 @@
+package main
+
+// Outcome tells whether and why a game is over
+type Outcome int
+
+// game outcomes. The fifty-move rule and threefold repetition allow a player to claim a draw,
+// the other draws end the game automatically.
+const (
+	Ongoing Outcome = iota
+	Checkmate
+	Stalemate
+	InsufficientMaterial
+	FivefoldRepetition
+	SeventyFiveMoveRule
+	ThreefoldRepetition
+	FiftyMoveRule
+)
+
+// darkSquares has a bit for every dark square, A1 is dark
+const darkSquares uint64 = 0xaa55aa55aa55aa55
+
+// GameStatus is the state of the game in a position.
+type GameStatus struct {
+	Outcome Outcome
+	Winner  int // White or Black after checkmate, Empty otherwise
+}
+
+// GameStatus reports whether the game is over in this position and why. Checkmate and
+// stalemate take precedence over the draw rules.
+func (p *Position) GameStatus() GameStatus {
+	status := GameStatus{Outcome: Ongoing, Winner: Empty}
+	switch {
+	case len(p.LegalMoves()) == 0:
+		if p.InCheck(p.side) {
+			status.Outcome = Checkmate
+			status.Winner = p.side ^ 1
+		} else {
+			status.Outcome = Stalemate
+		}
+	case p.InsufficientMaterial():
+		status.Outcome = InsufficientMaterial
+	case p.RepetitionCount() >= 5:
+		status.Outcome = FivefoldRepetition
+	case p.halfmoveClock >= 150:
+		status.Outcome = SeventyFiveMoveRule
+	case p.RepetitionCount() >= 3:
+		status.Outcome = ThreefoldRepetition
+	case p.halfmoveClock >= 100:
+		status.Outcome = FiftyMoveRule
+	}
+	return status
+}
+
+// Over reports whether the game has ended, or a draw can be claimed
+func (s GameStatus) Over() bool {
+	return s.Outcome != Ongoing
+}
+
+// Result returns the result in PGN notation: "1-0", "0-1", "1/2-1/2", or "*" while the game goes on
+func (s GameStatus) Result() string {
+	switch {
+	case s.Outcome == Ongoing:
+		return "*"
+	case s.Outcome != Checkmate:
+		return "1/2-1/2"
+	case s.Winner == White:
+		return "1-0"
+	default:
+		return "0-1"
+	}
+}
+
+// Reason describes why the game is over, as used in PGN comments and xboard result lines
+func (s GameStatus) Reason() string {
+	switch s.Outcome {
+	case Checkmate:
+		if s.Winner == White {
+			return "White mates"
+		}
+		return "Black mates"
+	case Stalemate:
+		return "Stalemate"
+	case InsufficientMaterial:
+		return "Insufficient material"
+	case FivefoldRepetition:
+		return "Fivefold repetition"
+	case SeventyFiveMoveRule:
+		return "75 move rule"
+	case ThreefoldRepetition:
+		return "Threefold repetition"
+	case FiftyMoveRule:
+		return "50 move rule"
+	}
+	return "Game in progress"
+}
+
+// String formats the status as a result with the reason in braces, like "1-0 {White mates}"
+func (s GameStatus) String() string {
+	return s.Result() + " {" + s.Reason() + "}"
+}
+
+// RepetitionCount returns how often the current position has occurred in the game, counting
+// itself. Only positions since the last capture or pawn move can repeat.
+func (p *Position) RepetitionCount() int {
+	count := 1
+	n := len(p.undo)
+	for i := 2; i <= p.halfmoveClock && i <= n; i += 2 {
+		if p.undo[n-i].hash == p.hash {
+			count++
+		}
+	}
+	return count
+}
+
+// repeated reports whether the current position has occurred before, the search scores
+// it as a draw already
+func (p *Position) repeated() bool {
+	n := len(p.undo)
+	for i := 4; i <= p.halfmoveClock && i <= n; i += 2 {
+		if p.undo[n-i].hash == p.hash {
+			return true
+		}
+	}
+	return false
+}
+
+// InsufficientMaterial reports whether neither side can possibly mate: only kings are left,
+// or a single minor piece, or any number of bishops that all stand on squares of one colour.
+func (p *Position) InsufficientMaterial() bool {
+	for color := White; color <= Black; color++ {
+		if p.pieceBB[color][Pawn]|p.pieceBB[color][Rook]|p.pieceBB[color][Queen] != 0 {
+			return false
+		}
+	}
+	knights := p.pieceBB[White][Knight] | p.pieceBB[Black][Knight]
+	bishops := p.pieceBB[White][Bishop] | p.pieceBB[Black][Bishop]
+	if popCount(knights|bishops) <= 1 {
+		return true
+	}
+	return knights == 0 && (bishops&darkSquares == 0 || bishops&^darkSquares == 0)
+}
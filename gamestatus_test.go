@@ -0,0 +1,98 @@
+package main
+
+import "testing"
+
+func TestGameStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		outcome Outcome
+		winner  int
+	}{
+		{"start position", StartFEN, Ongoing, Empty},
+		{"checkmate", "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", Checkmate, Black},
+		{"stalemate", "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", Stalemate, Empty},
+		{"K vs K", "8/8/4k3/8/8/3K4/8/8 w - - 0 1", InsufficientMaterial, Empty},
+		{"KB vs K", "8/8/4k3/8/8/3K4/8/2B5 w - - 0 1", InsufficientMaterial, Empty},
+		{"KN vs K", "8/8/4k3/8/8/3K4/8/6n1 w - - 0 1", InsufficientMaterial, Empty},
+		{"bishops on one colour", "5b2/8/4k3/8/8/3K4/8/2B5 w - - 0 1", InsufficientMaterial, Empty},
+		{"bishops on both colours", "2b5/8/4k3/8/8/3K4/8/2B5 w - - 0 1", Ongoing, Empty},
+		{"KN vs KB", "2b5/8/4k3/8/8/3K4/8/6N1 w - - 0 1", Ongoing, Empty},
+		{"KNN vs K", "8/8/4k3/8/8/3K4/8/1N4N1 w - - 0 1", Ongoing, Empty},
+		{"KP vs K", "8/8/4k3/8/8/3K4/4P3/8 w - - 0 1", Ongoing, Empty},
+		{"49 moves", "8/8/4k3/8/8/3K4/8/R7 w - - 99 80", Ongoing, Empty},
+		{"50 move rule", "8/8/4k3/8/8/3K4/8/R7 w - - 100 80", FiftyMoveRule, Empty},
+		{"75 move rule", "8/8/4k3/8/8/3K4/8/R7 w - - 150 80", SeventyFiveMoveRule, Empty},
+		{"mate on the hundredth half-move", "R5k1/5ppp/8/8/8/8/8/6K1 b - - 100 80", Checkmate, White},
+		{"mate after 75 moves", "R5k1/5ppp/8/8/8/8/8/6K1 b - - 150 80", Checkmate, White},
+		{"stalemate on the hundredth half-move", "7k/5Q2/6K1/8/8/8/8/8 b - - 100 80", Stalemate, Empty},
+	}
+	for _, tt := range tests {
+		p, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		status := p.GameStatus()
+		if status.Outcome != tt.outcome || status.Winner != tt.winner {
+			t.Errorf("%s: got %v, outcome %d winner %d, want outcome %d winner %d",
+				tt.name, status, status.Outcome, status.Winner, tt.outcome, tt.winner)
+		}
+	}
+}
+
+func TestRepetition(t *testing.T) {
+	p := NewPosition()
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	// the start position occurs again after every round of knight moves
+	for round := 1; round <= 4; round++ {
+		for _, s := range shuffle {
+			m, err := p.ParseMove(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.MakeMove(m)
+		}
+		if got := p.RepetitionCount(); got != round+1 {
+			t.Fatalf("round %d: repetition count %d, want %d", round, got, round+1)
+		}
+		want := Ongoing
+		switch {
+		case round+1 >= 5:
+			want = FivefoldRepetition
+		case round+1 >= 3:
+			want = ThreefoldRepetition
+		}
+		if got := p.GameStatus().Outcome; got != want {
+			t.Errorf("round %d: outcome %d, want %d", round, got, want)
+		}
+	}
+
+	// a pawn move makes the earlier positions unreachable
+	m, err := p.ParseMove("e2e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.MakeMove(m)
+	if got := p.RepetitionCount(); got != 1 {
+		t.Errorf("repetition count after a pawn move = %d, want 1", got)
+	}
+}
+
+func TestGameStatusString(t *testing.T) {
+	tests := []struct {
+		status GameStatus
+		want   string
+	}{
+		{GameStatus{Ongoing, Empty}, "* {Game in progress}"},
+		{GameStatus{Checkmate, White}, "1-0 {White mates}"},
+		{GameStatus{Checkmate, Black}, "0-1 {Black mates}"},
+		{GameStatus{Stalemate, Empty}, "1/2-1/2 {Stalemate}"},
+		{GameStatus{ThreefoldRepetition, Empty}, "1/2-1/2 {Threefold repetition}"},
+		{GameStatus{FiftyMoveRule, Empty}, "1/2-1/2 {50 move rule}"},
+	}
+	for _, tt := range tests {
+		if got := tt.status.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+// king safety scores; they only apply to the opening score, so the term fades out with the
+// game phase as material comes off the board
+var (
+	// shieldPenalty is indexed by the distance of the nearest own pawn in front of the king on
+	// each of the three files around it; 0 means there is none
+	shieldPenalty = [8]int{-25, 0, -10, -20, -25, -25, -25, -25}
+
+	// stormPenalty is indexed by the distance of the nearest enemy pawn coming towards the king
+	// on each of the three files around it; 0 means there is none
+	stormPenalty = [8]int{0, -5, -25, -15, -5, 0, 0, 0}
+
+	halfOpenKingFilePenalty = -15 // no own pawn on a file next to the king
+	openKingFilePenalty     = -10 // and no enemy pawn either, added to halfOpenKingFilePenalty
+)
+
+// attackWeight is what an enemy piece adds to the attack units for every king zone square it attacks
+var attackWeight = [...]int{0, 2, 2, 3, 5, 0, 0}
+
+// kingDangerTable turns attack units into a penalty that grows faster than the number of
+// attackers, because a coordinated attack of several pieces is much more dangerous
+var kingDangerTable = [64]int{
+	0, 0, 0, 1, 3, 5, 7, 9,
+	12, 16, 20, 24, 28, 33, 39, 45,
+	51, 57, 64, 72, 80, 88, 96, 105,
+	115, 125, 135, 145, 156, 168, 180, 192,
+	204, 217, 231, 245, 259, 273, 288, 304,
+	320, 336, 352, 369, 387, 405, 423, 441,
+	460, 480, 500, 500, 500, 500, 500, 500,
+	500, 500, 500, 500, 500, 500, 500, 500,
+}
+
+// kingSafety returns the opening and endgame king safety score of color's king: the pawn
+// shield, enemy pawn storms and open files around the king, and the attack on the king zone
+func (p *Position) kingSafety(color int) [2]int {
+	return [2]int{p.kingShelter(color) - p.kingDanger(color), 0}
+}
+
+// kingShelter scores the pawns on the king's file and the files beside it
+func (p *Position) kingShelter(color int) int {
+	king := p.kingSquare(color)
+	kingFile, kingRank := squareFile[king], squareRank[king]
+	ahead := forwardRanksMask[color][kingRank]
+	own, enemy := p.pieceBB[color][Pawn], p.pieceBB[color^1][Pawn]
+
+	score := 0
+	for f := maxInt(kingFile-1, 0); f <= minInt(kingFile+1, 7); f++ {
+		shield := own & fileMask[f] & ahead
+		storm := enemy & fileMask[f] & ahead
+		score += shieldPenalty[pawnDistance(color, kingRank, shield)]
+
+		stormDistance := pawnDistance(color, kingRank, storm)
+		penalty := stormPenalty[stormDistance]
+		// a storming pawn stuck on an own pawn opens no lines
+		if shield != 0 && pawnDistance(color, kingRank, shield) == stormDistance-1 {
+			penalty /= 2
+		}
+		score += penalty
+
+		if own&fileMask[f] == 0 {
+			score += halfOpenKingFilePenalty
+			if enemy&fileMask[f] == 0 {
+				score += openKingFilePenalty
+			}
+		}
+	}
+	return score
+}
+
+// pawnDistance returns how many ranks the nearest of the pawns in front of a king on kingRank
+// is away from it, or 0 if there are none
+func pawnDistance(color, kingRank int, pawns uint64) int {
+	switch {
+	case pawns == 0:
+		return 0
+	case color == White:
+		return squareRank[lsb(pawns)] - kingRank
+	default:
+		return kingRank - squareRank[msb(pawns)]
+	}
+}
+
+// kingDanger counts the enemy pieces attacking the squares around the king and the attack
+// units they bring, and returns the penalty from kingDangerTable. A lone attacker is ignored.
+func (p *Position) kingDanger(color int) int {
+	king := p.kingSquare(color)
+	zone := kingAttacks[king] | squareBB(king)
+	// the squares in front of the king belong to the zone as well
+	if color == White {
+		zone |= zone << 8
+	} else {
+		zone |= zone >> 8
+	}
+
+	occupied := p.occupied()
+	attackers, units := 0, 0
+	for piece := Knight; piece <= Queen; piece++ {
+		for b := p.pieceBB[color^1][piece]; b != 0; {
+			attacks := pieceAttacks(piece, popLSB(&b), occupied) & zone
+			if attacks != 0 {
+				attackers++
+				units += attackWeight[piece] * popCount(attacks)
+			}
+		}
+	}
+	if attackers < 2 {
+		return 0
+	}
+	return kingDangerTable[minInt(units, len(kingDangerTable)-1)]
+}
+
+// pieceAttacks returns the squares a knight, bishop, rook or queen on sq attacks
+func pieceAttacks(piece, sq int, occupied uint64) uint64 {
+	switch piece {
+	case Knight:
+		return knightAttacks[sq]
+	case Bishop:
+		return bishopAttacks(sq, occupied)
+	case Rook:
+		return rookAttacks(sq, occupied)
+	case Queen:
+		return queenAttacks(sq, occupied)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestKingShelter(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		color int
+		want  int
+	}{
+		{"full shield", "4k3/8/8/8/8/8/5PPP/6K1 w - - 0 1", White, 0},
+		{"advanced shield pawn", "4k3/8/8/8/8/6P1/5P1P/6K1 w - - 0 1", White,
+			shieldPenalty[2]},
+		{"open file", "4k3/8/8/8/8/8/5P1P/6K1 w - - 0 1", White,
+			shieldPenalty[0] + halfOpenKingFilePenalty + openKingFilePenalty},
+		{"half-open file", "4k3/6p1/8/8/8/8/5P1P/6K1 w - - 0 1", White,
+			shieldPenalty[0] + halfOpenKingFilePenalty},
+		{"pawn storm", "4k3/8/8/8/7p/8/5PPP/6K1 w - - 0 1", White, stormPenalty[3]},
+		{"blocked pawn storm", "4k3/8/8/8/8/7p/5PPP/6K1 w - - 0 1", White, stormPenalty[2] / 2},
+		{"black king", "6k1/5p1p/6p1/8/8/8/8/4K3 b - - 0 1", Black, shieldPenalty[2]},
+	}
+	for _, tt := range tests {
+		pos, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := pos.kingShelter(tt.color); got != tt.want {
+			t.Errorf("%s: kingShelter = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKingDanger(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want int
+	}{
+		{"no attackers", "4k3/8/8/8/8/8/5PPP/6K1 w - - 0 1", 0},
+		{"lone attacker", "4k3/8/8/8/7q/8/5PPP/6K1 w - - 0 1", 0},
+		// the queen hits f2, g3, h3 and h2, the knight g2 and h3
+		{"queen and knight", "4k3/8/8/8/5n1q/8/5PPP/6K1 w - - 0 1",
+			kingDangerTable[4*attackWeight[Queen]+2*attackWeight[Knight]]},
+	}
+	for _, tt := range tests {
+		pos, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := pos.kingDanger(White); got != tt.want {
+			t.Errorf("%s: kingDanger = %d, want %d", tt.name, got, tt.want)
+		}
+		if got, want := pos.kingSafety(White), [2]int{pos.kingShelter(White) - tt.want, 0}; got != want {
+			t.Errorf("%s: kingSafety = %v, want %v", tt.name, got, want)
+		}
+	}
+}
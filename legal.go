@@ -0,0 +1,138 @@
+package main
+
+// checkInfo describes the checks and pins against the king of the side to move.
+// It is computed once per position so each move can be tested without making it.
+type checkInfo struct {
+	king     int    // square of the king of the side to move
+	checkers uint64 // enemy pieces giving check
+	pinned   uint64 // own pieces that may only move along the line to their king
+}
+
+// kingSquare returns the square of the king of the given color
+func (p *Position) kingSquare(color int) int {
+	return lsb(p.pieceBB[color][King])
+}
+
+// IsSquareAttacked reports whether any piece of color byColor attacks the square sq.
+func (p *Position) IsSquareAttacked(sq, byColor int) bool {
+	return p.attackersTo(sq, p.occupied())&p.colorBB[byColor] != 0
+}
+
+// InCheck reports whether the king of the given color is attacked.
+func (p *Position) InCheck(color int) bool {
+	return p.IsSquareAttacked(p.kingSquare(color), color^1)
+}
+
+// attackersTo returns the pieces of both colors attacking sq, with sliders
+// blocked by the given occupied squares.
+func (p *Position) attackersTo(sq int, occupied uint64) uint64 {
+	bishops := p.pieceBB[White][Bishop] | p.pieceBB[Black][Bishop] | p.pieceBB[White][Queen] | p.pieceBB[Black][Queen]
+	rooks := p.pieceBB[White][Rook] | p.pieceBB[Black][Rook] | p.pieceBB[White][Queen] | p.pieceBB[Black][Queen]
+
+	// a pawn of one color attacks sq from the squares a pawn of the other color would capture on
+	return pawnAttacks[Black][sq]&p.pieceBB[White][Pawn] |
+		pawnAttacks[White][sq]&p.pieceBB[Black][Pawn] |
+		knightAttacks[sq]&(p.pieceBB[White][Knight]|p.pieceBB[Black][Knight]) |
+		kingAttacks[sq]&(p.pieceBB[White][King]|p.pieceBB[Black][King]) |
+		bishopAttacks(sq, occupied)&bishops |
+		rookAttacks(sq, occupied)&rooks
+}
+
+// computeCheckInfo finds the checkers and the pinned pieces of the side to move.
+func (p *Position) computeCheckInfo() checkInfo {
+	var ci checkInfo
+	us := p.side
+	them := us ^ 1
+	ci.king = p.kingSquare(us)
+	occupied := p.occupied()
+	ci.checkers = p.attackersTo(ci.king, occupied) & p.colorBB[them]
+
+	// an enemy slider that would see the king on an empty board pins
+	// the only piece standing between them, if that piece is ours
+	snipers := rookAttacks(ci.king, 0)&(p.pieceBB[them][Rook]|p.pieceBB[them][Queen]) |
+		bishopAttacks(ci.king, 0)&(p.pieceBB[them][Bishop]|p.pieceBB[them][Queen])
+	for snipers != 0 {
+		between := betweenBB[ci.king][popLSB(&snipers)] & occupied
+		if popCount(between) == 1 {
+			ci.pinned |= between & p.colorBB[us]
+		}
+	}
+	return ci
+}
+
+// GenerateLegalMoves appends every legal move for the side to move to moves
+// and returns the extended slice.
+func (p *Position) GenerateLegalMoves(moves []Move) []Move {
+	return p.filterLegal(p.GeneratePseudoLegalMoves(moves), len(moves))
+}
+
+// GenerateLegalCaptures appends the legal captures and promotions for the side to move
+// to moves and returns the extended slice.
+func (p *Position) GenerateLegalCaptures(moves []Move) []Move {
+	return p.filterLegal(p.GeneratePseudoLegalCaptures(moves), len(moves))
+}
+
+// filterLegal drops the moves from index start on that leave the own king in check
+func (p *Position) filterLegal(moves []Move, start int) []Move {
+	ci := p.computeCheckInfo()
+
+	n := start
+	for _, m := range moves[start:] {
+		if p.isLegal(m, &ci) {
+			moves[n] = m
+			n++
+		}
+	}
+	return moves[:n]
+}
+
+// LegalMoves returns all legal moves for the side to move.
+func (p *Position) LegalMoves() []Move {
+	return p.GenerateLegalMoves(make([]Move, 0, 64))
+}
+
+// isLegal tests whether the pseudo-legal move m keeps the own king out of check.
+func (p *Position) isLegal(m Move, ci *checkInfo) bool {
+	from, to := m.From(), m.To()
+	them := p.side ^ 1
+	occupied := p.occupied()
+
+	if from == ci.king {
+		if m.IsCastle() {
+			// no castling out of, through or into check
+			if ci.checkers != 0 {
+				return false
+			}
+			return !p.IsSquareAttacked(from+(to-from)/2, them) && !p.IsSquareAttacked(to, them)
+		}
+		// look through the king's old square, it must not hide a slider attack
+		return p.attackersTo(to, occupied&^squareBB(from))&p.colorBB[them] == 0
+	}
+
+	if m.IsEnPassant() {
+		// en passant removes two pieces from one rank, which can uncover a check
+		// that the pin detection does not see; test the occupancy after the capture
+		captured := to - 8
+		if p.side == Black {
+			captured = to + 8
+		}
+		after := occupied&^squareBB(from)&^squareBB(captured) | squareBB(to)
+		return p.attackersTo(ci.king, after)&p.colorBB[them]&^squareBB(captured) == 0
+	}
+
+	if ci.checkers != 0 {
+		// a single check must be answered by capturing the checker or blocking the line
+		if ci.checkers&(ci.checkers-1) != 0 {
+			return false
+		}
+		checker := lsb(ci.checkers)
+		if (betweenBB[ci.king][checker]|ci.checkers)&squareBB(to) == 0 {
+			return false
+		}
+	}
+
+	if ci.pinned&squareBB(from) != 0 {
+		return lineBB[ci.king][from]&squareBB(to) != 0
+	}
+	return true
+}
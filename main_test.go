@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain fills the precomputed tables as main does before running the tests
+func TestMain(m *testing.M) {
+	initSquareScoreTable()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,228 @@
+package main
+
+// undoInfo keeps the part of the position that a move destroys and UnmakeMove restores
+type undoInfo struct {
+	move          Move
+	captured      int // piece type captured by the move, or Empty
+	castling      int
+	epSquare      int
+	halfmoveClock int
+	hash          uint64
+}
+
+// castlingMask[sq] is and-ed into the castling rights whenever a move starts or ends on sq,
+// so moving a king or rook, or capturing a rook, drops the matching rights
+var castlingMask [64]int
+
+// initCastlingMask fills castlingMask from the king and rook home squares
+func initCastlingMask() {
+	for sq := range castlingMask {
+		castlingMask[sq] = castleAll
+	}
+	castlingMask[A1] &^= castleWhiteQueenSide
+	castlingMask[H1] &^= castleWhiteKingSide
+	castlingMask[E1] &^= castleWhiteKingSide | castleWhiteQueenSide
+	castlingMask[A8] &^= castleBlackQueenSide
+	castlingMask[H8] &^= castleBlackKingSide
+	castlingMask[E8] &^= castleBlackKingSide | castleBlackQueenSide
+}
+
+// Clone returns a copy of the position with its own undo stack.
+func (p *Position) Clone() Position {
+	q := *p
+	q.undo = append([]undoInfo(nil), p.undo...)
+	return q
+}
+
+// clearBoard removes all pieces from the board
+func (p *Position) clearBoard() {
+	for sq := 0; sq < 64; sq++ {
+		p.pieces[sq] = Empty
+		p.colors[sq] = Empty
+	}
+	p.pieceBB = [2][6]uint64{}
+	p.colorBB = [2]uint64{}
+	p.hash = 0
+	p.pawnHash = 0
+	p.phase = 0
+}
+
+// putPiece places a piece of the given color on an empty square
+func (p *Position) putPiece(sq, piece, color int) {
+	p.pieces[sq] = piece
+	p.colors[sq] = color
+	p.pieceBB[color][piece] |= squareBB(sq)
+	p.colorBB[color] |= squareBB(sq)
+	p.hash ^= zobristPieces[color][piece][sq]
+	if piece == Pawn {
+		p.pawnHash ^= zobristPieces[color][Pawn][sq]
+	}
+	p.phase += phaseWeight[piece]
+}
+
+// removePiece clears the square sq
+func (p *Position) removePiece(sq int) {
+	piece, color := p.pieces[sq], p.colors[sq]
+	p.pieceBB[color][piece] &^= squareBB(sq)
+	p.colorBB[color] &^= squareBB(sq)
+	p.hash ^= zobristPieces[color][piece][sq]
+	if piece == Pawn {
+		p.pawnHash ^= zobristPieces[color][Pawn][sq]
+	}
+	p.phase -= phaseWeight[piece]
+	p.pieces[sq] = Empty
+	p.colors[sq] = Empty
+}
+
+// occupied returns the bitboard of all pieces on the board
+func (p *Position) occupied() uint64 {
+	return p.colorBB[White] | p.colorBB[Black]
+}
+
+// movePiece moves the piece on from to the empty square to
+func (p *Position) movePiece(from, to int) {
+	piece, color := p.pieces[from], p.colors[from]
+	p.removePiece(from)
+	p.putPiece(to, piece, color)
+}
+
+// castlingRookSquares returns the rook's source and target square for a castling king move
+func castlingRookSquares(kingTo int) (int, int) {
+	switch Square(kingTo) {
+	case G1:
+		return int(H1), int(F1)
+	case C1:
+		return int(A1), int(D1)
+	case G8:
+		return int(H8), int(F8)
+	default: // C8
+		return int(A8), int(D8)
+	}
+}
+
+// MakeMove plays m on the board and pushes the information needed to take it back.
+// The move must be pseudo-legal for the side to move.
+func (p *Position) MakeMove(m Move) {
+	from, to := m.From(), m.To()
+	us := p.side
+	them := us ^ 1
+
+	u := undoInfo{
+		move:          m,
+		captured:      Empty,
+		castling:      p.castling,
+		epSquare:      p.epSquare,
+		halfmoveClock: p.halfmoveClock,
+		hash:          p.hash,
+	}
+
+	p.halfmoveClock++
+	if p.pieces[from] == Pawn {
+		p.halfmoveClock = 0
+	}
+
+	if m.IsEnPassant() {
+		captured := to - 8
+		if us == Black {
+			captured = to + 8
+		}
+		u.captured = Pawn
+		p.removePiece(captured)
+		p.halfmoveClock = 0
+	} else if m.IsCapture() {
+		u.captured = p.pieces[to]
+		p.removePiece(to)
+		p.halfmoveClock = 0
+	}
+
+	p.movePiece(from, to)
+	if promotion := m.Promotion(); promotion != Empty {
+		p.removePiece(to)
+		p.putPiece(to, promotion, us)
+	}
+
+	if m.IsCastle() {
+		rookFrom, rookTo := castlingRookSquares(to)
+		p.movePiece(rookFrom, rookTo)
+	}
+
+	// only remember the en-passant square when an enemy pawn can actually capture there
+	if p.epSquare != noSquare {
+		p.hash ^= zobristEnPassant[squareFile[p.epSquare]]
+	}
+	p.epSquare = noSquare
+	if m.IsDoublePush() {
+		for _, adjacent := range [...]int{to - 1, to + 1} {
+			if squareRank[adjacent] == squareRank[to] && p.pieces[adjacent] == Pawn && p.colors[adjacent] == them {
+				p.epSquare = (from + to) / 2
+			}
+		}
+		if p.epSquare != noSquare {
+			p.hash ^= zobristEnPassant[squareFile[p.epSquare]]
+		}
+	}
+
+	p.hash ^= zobristCastling[p.castling]
+	p.castling &= castlingMask[from] & castlingMask[to]
+	p.hash ^= zobristCastling[p.castling]
+
+	if us == Black {
+		p.fullmoveNumber++
+	}
+	p.side = them
+	p.hash ^= zobristSide
+	p.hply++
+	p.undo = append(p.undo, u)
+
+	if debugChecks {
+		p.checkHash("MakeMove " + m.String())
+		p.checkPhase("MakeMove " + m.String())
+	}
+}
+
+// UnmakeMove takes back the last move made with MakeMove.
+func (p *Position) UnmakeMove() {
+	u := p.undo[len(p.undo)-1]
+	p.undo = p.undo[:len(p.undo)-1]
+
+	m := u.move
+	from, to := m.From(), m.To()
+	them := p.side
+	us := them ^ 1
+
+	if m.IsCastle() {
+		rookFrom, rookTo := castlingRookSquares(to)
+		p.movePiece(rookTo, rookFrom)
+	}
+
+	if m.Promotion() != Empty {
+		p.removePiece(to)
+		p.putPiece(to, Pawn, us)
+	}
+	p.movePiece(to, from)
+
+	if m.IsEnPassant() {
+		captured := to - 8
+		if us == Black {
+			captured = to + 8
+		}
+		p.putPiece(captured, Pawn, them)
+	} else if u.captured != Empty {
+		p.putPiece(to, u.captured, them)
+	}
+
+	p.castling = u.castling
+	p.epSquare = u.epSquare
+	p.halfmoveClock = u.halfmoveClock
+	if us == Black {
+		p.fullmoveNumber--
+	}
+	p.side = us
+	p.hash = u.hash
+	p.hply--
+
+	if debugChecks {
+		p.checkHash("UnmakeMove " + m.String())
+		p.checkPhase("UnmakeMove " + m.String())
+	}
+}
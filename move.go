@@ -0,0 +1,94 @@
+package main
+
+import "fmt"
+
+// Move packs a move into 32 bits:
+// bits 0-5 hold the source square, bits 6-11 the target square,
+// bits 12-14 the promotion piece (0 = none) and bits 15-18 the move flags.
+type Move uint32
+
+// NoMove is the zero Move; it never describes a real move (a1 to a1)
+const NoMove Move = 0
+
+// move flags stored in bits 15-18 of a Move
+const (
+	flagCapture    = 1 << iota // a piece is captured (also set for en passant)
+	flagEnPassant              // pawn captures en passant
+	flagCastle                 // king castles, the rook is moved as well
+	flagDoublePush             // pawn advances two squares from its start rank
+)
+
+// newMove packs source, target, promotion piece and flags into a Move.
+// Pass Empty as promotion when the move is not a promotion.
+func newMove(from, to, promotion, flags int) Move {
+	if promotion == Empty {
+		promotion = 0
+	}
+	return Move(from | to<<6 | promotion<<12 | flags<<15)
+}
+
+// From returns the source square of the move
+func (m Move) From() int {
+	return int(m & 0x3f)
+}
+
+// To returns the target square of the move
+func (m Move) To() int {
+	return int(m>>6) & 0x3f
+}
+
+// Promotion returns the piece a pawn promotes to, or Empty
+func (m Move) Promotion() int {
+	promotion := int(m>>12) & 0x7
+	if promotion == 0 {
+		return Empty
+	}
+	return promotion
+}
+
+// flags returns the flag bits of the move
+func (m Move) flags() int {
+	return int(m>>15) & 0xf
+}
+
+// IsCapture reports whether the move captures a piece (including en passant)
+func (m Move) IsCapture() bool {
+	return m.flags()&flagCapture != 0
+}
+
+// IsEnPassant reports whether the move is an en-passant capture
+func (m Move) IsEnPassant() bool {
+	return m.flags()&flagEnPassant != 0
+}
+
+// IsCastle reports whether the move is a castling move
+func (m Move) IsCastle() bool {
+	return m.flags()&flagCastle != 0
+}
+
+// IsDoublePush reports whether the move is a two-square pawn advance
+func (m Move) IsDoublePush() bool {
+	return m.flags()&flagDoublePush != 0
+}
+
+// String returns the move in coordinate notation as used by UCI ("e2e4", "e7e8q").
+func (m Move) String() string {
+	if m == NoMove {
+		return "0000"
+	}
+	s := IndexToAlgebraic(m.From()) + IndexToAlgebraic(m.To())
+	if promotion := m.Promotion(); promotion != Empty {
+		s += string(pieceLetters[promotion] + 'a' - 'A')
+	}
+	return s
+}
+
+// ParseMove finds the legal move written in coordinate notation ("e2e4", "e7e8q").
+func (p *Position) ParseMove(s string) (Move, error) {
+	for _, m := range p.LegalMoves() {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return NoMove, fmt.Errorf("illegal move %q", s)
+}
@@ -0,0 +1,145 @@
+package main
+
+// promotionPieces lists the pieces a pawn can promote to, strongest first
+var promotionPieces = [...]int{Queen, Rook, Bishop, Knight}
+
+// pawnStartRank per color (0 = rank 1 ... 7 = rank 8)
+var pawnStartRank = [2]int{1, 6}
+
+// GeneratePseudoLegalMoves appends every pseudo-legal move for the side to move to moves
+// and returns the extended slice. Moves may still leave the own king in check.
+func (p *Position) GeneratePseudoLegalMoves(moves []Move) []Move {
+	return p.generateMoves(moves, true)
+}
+
+// GeneratePseudoLegalCaptures appends the pseudo-legal captures and promotions for the
+// side to move, the moves a quiescence search looks at.
+func (p *Position) GeneratePseudoLegalCaptures(moves []Move) []Move {
+	return p.generateMoves(moves, false)
+}
+
+// generateMoves generates all pseudo-legal moves, or with quiets false only
+// captures, en-passant captures and promotions.
+func (p *Position) generateMoves(moves []Move, quiets bool) []Move {
+	us := p.side
+	occupied := p.occupied()
+	targets := p.colorBB[us^1]
+	if quiets {
+		targets = ^p.colorBB[us]
+	}
+
+	for b := p.pieceBB[us][Pawn]; b != 0; {
+		moves = p.genPawnMoves(moves, popLSB(&b), quiets)
+	}
+	for b := p.pieceBB[us][Knight]; b != 0; {
+		from := popLSB(&b)
+		moves = p.genTargetMoves(moves, from, knightAttacks[from]&targets)
+	}
+	for b := p.pieceBB[us][Bishop]; b != 0; {
+		from := popLSB(&b)
+		moves = p.genTargetMoves(moves, from, bishopAttacks(from, occupied)&targets)
+	}
+	for b := p.pieceBB[us][Rook]; b != 0; {
+		from := popLSB(&b)
+		moves = p.genTargetMoves(moves, from, rookAttacks(from, occupied)&targets)
+	}
+	for b := p.pieceBB[us][Queen]; b != 0; {
+		from := popLSB(&b)
+		moves = p.genTargetMoves(moves, from, queenAttacks(from, occupied)&targets)
+	}
+	from := p.kingSquare(us)
+	moves = p.genTargetMoves(moves, from, kingAttacks[from]&targets)
+	if quiets {
+		moves = p.genCastlingMoves(moves, from)
+	}
+	return moves
+}
+
+// genTargetMoves adds a move to every square of the targets bitboard,
+// flagged as a capture where an enemy piece stands.
+func (p *Position) genTargetMoves(moves []Move, from int, targets uint64) []Move {
+	enemies := p.colorBB[p.side^1]
+	for targets != 0 {
+		to := popLSB(&targets)
+		if enemies&squareBB(to) != 0 {
+			moves = append(moves, newMove(from, to, Empty, flagCapture))
+		} else {
+			moves = append(moves, newMove(from, to, Empty, 0))
+		}
+	}
+	return moves
+}
+
+// genPawnMoves adds pushes, double pushes, captures, en-passant captures and promotions
+// of the pawn on from. Pushes use the pawnMoves offsets, captures the pawn attack bitboards.
+// Without quiets only pushes that promote are added.
+func (p *Position) genPawnMoves(moves []Move, from int, quiets bool) []Move {
+	us := p.side
+	forward := pawnMovesWhite[0]
+	if us == Black {
+		forward = pawnMovesBlack[0]
+	}
+
+	// push: the target square must be empty
+	to := from + forward
+	promotes := squareRank[to] == 0 || squareRank[to] == 7
+	if (quiets || promotes) && p.pieces[to] == Empty {
+		moves = addPawnMove(moves, from, to, 0)
+		if quiets && squareRank[from] == pawnStartRank[us] && p.pieces[to+forward] == Empty {
+			moves = append(moves, newMove(from, to+forward, Empty, flagDoublePush))
+		}
+	}
+
+	// diagonal: only captures, including en passant
+	for b := pawnAttacks[us][from] & p.colorBB[us^1]; b != 0; {
+		moves = addPawnMove(moves, from, popLSB(&b), flagCapture)
+	}
+	if p.epSquare != noSquare && pawnAttacks[us][from]&squareBB(p.epSquare) != 0 {
+		moves = append(moves, newMove(from, p.epSquare, Empty, flagCapture|flagEnPassant))
+	}
+	return moves
+}
+
+// addPawnMove adds a pawn move, expanding it into all promotions when it reaches the last rank
+func addPawnMove(moves []Move, from, to, flags int) []Move {
+	if squareRank[to] == 0 || squareRank[to] == 7 {
+		for _, promotion := range promotionPieces {
+			moves = append(moves, newMove(from, to, promotion, flags))
+		}
+		return moves
+	}
+	return append(moves, newMove(from, to, Empty, flags))
+}
+
+// genCastlingMoves adds castling moves when the right is still held and the squares
+// between king and rook are empty. Whether the king passes through check is left
+// to the legal move filter.
+func (p *Position) genCastlingMoves(moves []Move, from int) []Move {
+	if p.side == White {
+		if p.castling&castleWhiteKingSide != 0 && p.isEmpty(F1, G1) {
+			moves = append(moves, newMove(from, int(E1)+castlingKingSideWhite, Empty, flagCastle))
+		}
+		if p.castling&castleWhiteQueenSide != 0 && p.isEmpty(B1, C1, D1) {
+			moves = append(moves, newMove(from, int(E1)+castlingQueenSideWhite, Empty, flagCastle))
+		}
+	} else {
+		if p.castling&castleBlackKingSide != 0 && p.isEmpty(F8, G8) {
+			moves = append(moves, newMove(from, int(E8)+castlingKingSideBlack, Empty, flagCastle))
+		}
+		if p.castling&castleBlackQueenSide != 0 && p.isEmpty(B8, C8, D8) {
+			moves = append(moves, newMove(from, int(E8)+castlingQueenSideBlack, Empty, flagCastle))
+		}
+	}
+	return moves
+}
+
+// isEmpty reports whether all given squares are empty
+func (p *Position) isEmpty(squares ...Square) bool {
+	occupied := p.occupied()
+	for _, sq := range squares {
+		if occupied&squareBB(int(sq)) != 0 {
+			return false
+		}
+	}
+	return true
+}
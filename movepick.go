@@ -0,0 +1,114 @@
+package main
+
+// move ordering score bands, the picker hands out higher bands first:
+// hash move, winning captures (MVV-LVA), promotions, killers, quiet moves (history),
+// and losing captures last
+const (
+	scoreHashMove    = 1 << 30
+	scoreGoodCapture = 1 << 28
+	scorePromotion   = 1 << 27
+	scoreKiller      = 1 << 26
+	scoreBadCapture  = -(1 << 28)
+)
+
+// historyMax bounds the history scores, keeping them below the killer band
+const historyMax = 1 << 14
+
+// movePicker hands out the moves of one node, best scored first. Moves are selected
+// lazily, so after a cutoff the remaining moves never need to be sorted.
+type movePicker struct {
+	moves  []Move
+	scores [256]int
+	next   int
+}
+
+// newMovePicker scores the legal moves of the node at ply, the picker is reused per ply
+func (s *searcher) newMovePicker(moves []Move, ttMove Move, ply int) *movePicker {
+	mp := &s.pickers[ply]
+	mp.moves, mp.next = moves, 0
+	p := &s.pos
+	for i, m := range moves {
+		switch {
+		case m == ttMove:
+			mp.scores[i] = scoreHashMove
+		case m.IsCapture():
+			if p.goodCapture(m) {
+				mp.scores[i] = scoreGoodCapture + p.mvvLva(m)
+			} else {
+				mp.scores[i] = scoreBadCapture + p.mvvLva(m)
+			}
+		case m.Promotion() != Empty:
+			mp.scores[i] = scorePromotion + pieceValues[m.Promotion()]
+		case m == s.killers[ply][0]:
+			mp.scores[i] = scoreKiller + 1
+		case m == s.killers[ply][1]:
+			mp.scores[i] = scoreKiller
+		default:
+			mp.scores[i] = s.history[p.side][m.From()][m.To()]
+		}
+	}
+	return mp
+}
+
+// nextMove returns the best scored move not handed out yet and its score,
+// or false when all moves have been handed out
+func (mp *movePicker) nextMove() (Move, int, bool) {
+	if mp.next >= len(mp.moves) {
+		return NoMove, 0, false
+	}
+	best := mp.next
+	for i := mp.next + 1; i < len(mp.moves); i++ {
+		if mp.scores[i] > mp.scores[best] {
+			best = i
+		}
+	}
+	i := mp.next
+	mp.moves[i], mp.moves[best] = mp.moves[best], mp.moves[i]
+	mp.scores[i], mp.scores[best] = mp.scores[best], mp.scores[i]
+	mp.next++
+	return mp.moves[i], mp.scores[i], true
+}
+
+// mvvLva orders captures by most valuable victim (pieceValues), then least valuable attacker
+func (p *Position) mvvLva(m Move) int {
+	victim := Pawn
+	if !m.IsEnPassant() {
+		victim = p.pieces[m.To()]
+	}
+	return pieceValues[victim]*8 - p.pieces[m.From()]
+}
+
+// goodCapture reports whether a capture does not lose material. Taking a piece worth
+// at least as much as the capturing piece never does, otherwise SEE decides.
+func (p *Position) goodCapture(m Move) bool {
+	if !m.IsEnPassant() && pieceValues[p.pieces[m.To()]] >= pieceValues[p.pieces[m.From()]] {
+		return true
+	}
+	return p.SEE(m) >= 0
+}
+
+// updateQuietStats rewards the quiet move that caused a beta cutoff with a killer slot
+// and a history bonus, and lowers the history of the quiet moves tried before it
+func (s *searcher) updateQuietStats(m Move, tried []Move, depth, ply int) {
+	if s.killers[ply][0] != m {
+		s.killers[ply][1] = s.killers[ply][0]
+		s.killers[ply][0] = m
+	}
+
+	bonus := depth * depth
+	side := s.pos.side
+	s.addHistory(side, m, bonus)
+	for _, q := range tried {
+		s.addHistory(side, q, -bonus)
+	}
+}
+
+// addHistory adds bonus to the butterfly history of a move; the more extreme the entry
+// already is, the less it moves, which keeps it within historyMax
+func (s *searcher) addHistory(side int, m Move, bonus int) {
+	h := &s.history[side][m.From()][m.To()]
+	if bonus > historyMax {
+		bonus = historyMax
+	}
+	*h += bonus - *h*abs(bonus)/historyMax
+}
@@ -53,6 +53,10 @@ var QueenTargets [64][]int
 // KingTargets[sourceSquare] contains all legal target squares for a king from that source
 var KingTargets [64][]int
 
+// SliderRays[sourceSquare][direction] contains the target squares along a single ray, nearest first.
+// The direction index follows queenDirections: 0..3 are rook directions, 4..7 bishop directions
+var SliderRays [64][8][]int
+
 // knightMoves defines all possible moves for a knight (relative offsets)
 // Knights move in an L-shape: 2 squares in one direction, 1 square perpendicular
 var knightMoves = [...]int{
@@ -290,6 +294,19 @@ func initMoveTargets() {
 		QueenTargets[sq] = targets
 	}
 
+	// Initialize slider rays (one list per direction so generation can stop at blockers)
+	for sq := 0; sq < 64; sq++ {
+		for d, direction := range queenDirections {
+			var ray []int
+			target := sq + direction
+			for isSquareValid(target) && !isFileWrappingMove(target-direction, target) {
+				ray = append(ray, target)
+				target += direction
+			}
+			SliderRays[sq][d] = ray
+		}
+	}
+
 	// Initialize King targets
 	for sq := 0; sq < 64; sq++ {
 		var targets []int
@@ -313,3 +330,12 @@ func IndexToAlgebraic(idx int) string {
 	rank := idx / 8
 	return fmt.Sprintf("%c%d", 'a'+byte(file), rank+1)
 }
+
+// AlgebraicToIndex converts algebraic notation ("a1".."h8") to a board index (0..63).
+// It is the reverse of IndexToAlgebraic.
+func AlgebraicToIndex(s string) (int, error) {
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return noSquare, fmt.Errorf("invalid square %q", s)
+	}
+	return int(s[1]-'1')*8 + int(s[0]-'a'), nil
+}
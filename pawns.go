@@ -0,0 +1,186 @@
+package main
+
+import "sync/atomic"
+
+// pawn structure scores as opening and endgame pairs, in centipawns per pawn
+var (
+	isolatedPawnPenalty = [2]int{-10, -15}
+	doubledPawnPenalty  = [2]int{-10, -20} // for every pawn with a pawn of the same color in front of it
+	backwardPawnPenalty = [2]int{-8, -10}
+	connectedPawnBonus  = [2]int{10, 8} // for a pawn defended by a pawn
+	phalanxPawnBonus    = [2]int{6, 4}  // for a pawn with a pawn beside it on the same rank
+	pawnIslandPenalty   = [2]int{-5, -10}
+)
+
+// candidatePasserDivisor scales the passed pawn scores down for candidate passed pawns
+const candidatePasserDivisor = 2
+
+// fileMask[f] holds the squares of file f, adjacentFilesMask[f] those of the files beside it
+var fileMask [8]uint64
+var adjacentFilesMask [8]uint64
+
+// forwardRanksMask[color][r] holds the squares on the ranks in front of rank r, as seen by color
+var forwardRanksMask [2][8]uint64
+
+// passedPawnMask[color][sq] holds the squares in front of a pawn on sq, on its own and the
+// adjacent files, that must be free of enemy pawns for the pawn to be passed
+var passedPawnMask [2][64]uint64
+
+// initPawnMasks fills the file, rank and passed pawn masks
+func initPawnMasks() {
+	for sq := 0; sq < 64; sq++ {
+		fileMask[squareFile[sq]] |= squareBB(sq)
+	}
+	for f := 0; f < 8; f++ {
+		if f > 0 {
+			adjacentFilesMask[f] |= fileMask[f-1]
+		}
+		if f < 7 {
+			adjacentFilesMask[f] |= fileMask[f+1]
+		}
+	}
+	for r := 0; r < 8; r++ {
+		for sq := 0; sq < 64; sq++ {
+			if squareRank[sq] > r {
+				forwardRanksMask[White][r] |= squareBB(sq)
+			}
+			if squareRank[sq] < r {
+				forwardRanksMask[Black][r] |= squareBB(sq)
+			}
+		}
+	}
+	for color := White; color <= Black; color++ {
+		for sq := 0; sq < 64; sq++ {
+			f := squareFile[sq]
+			passedPawnMask[color][sq] = (fileMask[f] | adjacentFilesMask[f]) & forwardRanksMask[color][squareRank[sq]]
+		}
+	}
+}
+
+// pawnTableSize is the number of entries of the pawn hash table, a power of two
+const pawnTableSize = 1 << 14
+
+// pawnEntry caches the pawn structure score of a pawn hash. Like the transposition table
+// entries, key holds the pawn hash XOR data, so torn entries fail verification.
+//
+// data layout: bits 0-15 opening score, 16-31 endgame score (int16, White's point of view)
+type pawnEntry struct {
+	key  uint64
+	data uint64
+}
+
+// pawnTable caches pawn structure scores; pawns move rarely, so most lookups hit
+var pawnTable [pawnTableSize]pawnEntry
+
+// pawnStructure returns the opening and endgame pawn structure score from White's point of view,
+// from the pawn hash table if possible
+func (p *Position) pawnStructure() (int, int) {
+	entry := &pawnTable[p.pawnHash&(pawnTableSize-1)]
+	data := atomic.LoadUint64(&entry.data)
+	if atomic.LoadUint64(&entry.key)^data == p.pawnHash {
+		return int(int16(uint16(data))), int(int16(uint16(data >> 16)))
+	}
+
+	var score [2][2]int // per color, opening and endgame
+	for color := White; color <= Black; color++ {
+		structure, passed := p.evaluatePawns(color)
+		score[color] = [2]int{structure[0] + passed[0], structure[1] + passed[1]}
+	}
+	opening := score[White][0] - score[Black][0]
+	endgame := score[White][1] - score[Black][1]
+
+	data = uint64(uint16(int16(opening))) | uint64(uint16(int16(endgame)))<<16
+	atomic.StoreUint64(&entry.data, data)
+	atomic.StoreUint64(&entry.key, p.pawnHash^data)
+	return opening, endgame
+}
+
+// evaluatePawns scores the pawns of color. It returns the structure score for isolated,
+// doubled, backward, connected and phalanx pawns and the number of pawn islands, and
+// separately the score of the passed and candidate passed pawns.
+func (p *Position) evaluatePawns(color int) (score, passed [2]int) {
+	add := func(term [2]int) {
+		score[0] += term[0]
+		score[1] += term[1]
+	}
+
+	own, enemy := p.pieceBB[color][Pawn], p.pieceBB[color^1][Pawn]
+	for b := own; b != 0; {
+		sq := popLSB(&b)
+		file, rank := squareFile[sq], squareRank[sq]
+		relative := sq // square from White's side for the rank based tables
+		if color == Black {
+			relative = flipSquare[sq]
+		}
+		front := fileMask[file] & forwardRanksMask[color][rank]
+		behind := ^forwardRanksMask[color][rank] // this rank and the ranks behind it
+		neighbours := own & adjacentFilesMask[file]
+
+		doubled := own&front != 0
+		if doubled {
+			add(doubledPawnPenalty)
+		}
+
+		switch {
+		case !doubled && enemy&passedPawnMask[color][sq] == 0:
+			passed[0] += PassedPawnScore[relative]
+			passed[1] += PassedPawnEndgameScore[relative]
+		case !doubled && enemy&front == 0:
+			// candidate passed pawn: an open file ahead and at least as many pawns to support
+			// the advance as enemy pawns guarding the squares in front of it
+			helpers := popCount(neighbours & behind)
+			sentries := popCount(enemy & adjacentFilesMask[file] & forwardRanksMask[color][rank])
+			if helpers >= sentries {
+				passed[0] += PassedPawnScore[relative] / candidatePasserDivisor
+				passed[1] += PassedPawnEndgameScore[relative] / candidatePasserDivisor
+			}
+		}
+
+		switch {
+		case neighbours == 0:
+			add(isolatedPawnPenalty)
+		case neighbours&behind == 0 && p.isBackwardPawn(color, sq):
+			add(backwardPawnPenalty)
+		}
+
+		if pawnAttacks[color^1][sq]&own != 0 {
+			add(connectedPawnBonus)
+		}
+		if neighbours&rankMask(rank) != 0 {
+			add(phalanxPawnBonus)
+		}
+	}
+
+	// every group of pawns on neighbouring files beyond the first is a weakness
+	islands := 0
+	for file, inIsland := 0, false; file < 8; file++ {
+		occupied := own&fileMask[file] != 0
+		if occupied && !inIsland {
+			islands++
+		}
+		inIsland = occupied
+	}
+	if islands > 1 {
+		score[0] += pawnIslandPenalty[0] * (islands - 1)
+		score[1] += pawnIslandPenalty[1] * (islands - 1)
+	}
+	return score, passed
+}
+
+// isBackwardPawn reports whether the stop square of a pawn without support from behind is
+// controlled by an enemy pawn, so the pawn cannot safely advance to join its neighbours
+func (p *Position) isBackwardPawn(color, sq int) bool {
+	stop := sq + 8
+	if color == Black {
+		stop = sq - 8
+	}
+	if stop < 0 || stop >= 64 {
+		return false
+	}
+	return pawnAttacks[color][stop]&p.pieceBB[color^1][Pawn] != 0
+}
+
+// rankMask returns the squares of rank r
+func rankMask(r int) uint64 {
+	return 0xff << (8 * uint(r))
+}
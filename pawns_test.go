@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestEvaluatePawns(t *testing.T) {
+	// sum adds up opening and endgame terms, times is a term counted n times
+	sum := func(terms ...[2]int) [2]int {
+		var s [2]int
+		for _, term := range terms {
+			s[0] += term[0]
+			s[1] += term[1]
+		}
+		return s
+	}
+	times := func(term [2]int, n int) [2]int {
+		return [2]int{term[0] * n, term[1] * n}
+	}
+	passer := func(sq Square, divisor int) [2]int {
+		return [2]int{PassedPawnScore[sq] / divisor, PassedPawnEndgameScore[sq] / divisor}
+	}
+
+	tests := []struct {
+		name              string
+		fen               string
+		color             int
+		structure, passed [2]int
+	}{
+		{"isolated pawns", "4k3/p1p5/8/8/8/8/P1P5/4K3 w - - 0 1", White,
+			sum(times(isolatedPawnPenalty, 2), pawnIslandPenalty), [2]int{}},
+		{"doubled pawns", "4k3/2p5/8/8/8/2P5/2P5/4K3 w - - 0 1", White,
+			sum(doubledPawnPenalty, times(isolatedPawnPenalty, 2)), [2]int{}},
+		{"passed pawn", "4k3/p7/8/3P4/8/8/8/4K3 w - - 0 1", White,
+			isolatedPawnPenalty, passer(D5, 1)},
+		{"black passed pawn", "4k3/8/8/8/3p4/8/P7/4K3 b - - 0 1", Black,
+			isolatedPawnPenalty, passer(D5, 1)},
+		{"doubled passed pawns", "4k3/8/8/3P4/3P4/8/8/4K3 w - - 0 1", White,
+			sum(doubledPawnPenalty, times(isolatedPawnPenalty, 2)), passer(D5, 1)},
+		{"candidate passed pawn", "4k3/1p6/8/8/1PP5/8/8/4K3 w - - 0 1", White,
+			times(phalanxPawnBonus, 2), passer(C4, candidatePasserDivisor)},
+		{"outnumbered candidate", "4k3/1p1p4/8/8/2P5/8/8/4K3 w - - 0 1", White,
+			isolatedPawnPenalty, [2]int{}},
+		{"connected pawns", "4k3/2pp4/8/8/8/3P4/2P5/4K3 w - - 0 1", White,
+			connectedPawnBonus, [2]int{}},
+		{"pawn islands", "4k3/p1p1p1p1/8/8/8/8/P1P1P1P1/4K3 w - - 0 1", White,
+			sum(times(isolatedPawnPenalty, 4), times(pawnIslandPenalty, 3)), [2]int{}},
+	}
+	for _, tt := range tests {
+		pos, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		structure, passed := pos.evaluatePawns(tt.color)
+		if structure != tt.structure || passed != tt.passed {
+			t.Errorf("%s: structure %v, passed %v, want %v, %v", tt.name, structure, passed, tt.structure, tt.passed)
+		}
+	}
+}
+
+func TestPawnStructureCache(t *testing.T) {
+	pos, err := ParseFEN("4k3/1p1p4/8/8/1PP5/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	white, whitePassed := pos.evaluatePawns(White)
+	black, blackPassed := pos.evaluatePawns(Black)
+	wantOpening := white[0] + whitePassed[0] - black[0] - blackPassed[0]
+	wantEndgame := white[1] + whitePassed[1] - black[1] - blackPassed[1]
+	// the second call is answered from the pawn hash table
+	for i := 0; i < 2; i++ {
+		if opening, endgame := pos.pawnStructure(); opening != wantOpening || endgame != wantEndgame {
+			t.Errorf("call %d: pawnStructure = %d, %d, want %d, %d", i+1, opening, endgame, wantOpening, wantEndgame)
+		}
+	}
+}
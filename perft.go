@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// perftSuite holds the standard perft test positions with their published node counts
+var perftSuite = []struct {
+	name  string
+	fen   string
+	depth int
+	nodes uint64
+}{
+	{"start position", StartFEN, 5, 4865609},
+	{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 4, 4085603},
+	{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 6, 11030083},
+	{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 5, 15833292},
+	{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 4, 2103487},
+	{"position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", 4, 3894594},
+}
+
+// Perft counts the leaf nodes of the legal move tree to the given depth.
+func (p *Position) Perft(depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	moves := p.LegalMoves()
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var nodes uint64
+	for _, m := range moves {
+		p.MakeMove(m)
+		nodes += p.Perft(depth - 1)
+		p.UnmakeMove()
+	}
+	return nodes
+}
+
+// PerftDivide returns the perft count below each legal root move, keyed by the move in UCI notation.
+func (p *Position) PerftDivide(depth int) map[string]uint64 {
+	counts := make(map[string]uint64)
+	if depth < 1 {
+		return counts
+	}
+	for _, m := range p.LegalMoves() {
+		p.MakeMove(m)
+		counts[m.String()] = p.Perft(depth - 1)
+		p.UnmakeMove()
+	}
+	return counts
+}
+
+// runPerft implements the perft command line mode:
+//
+//	chess perft [-depth n] [-fen fen] [-divide]
+//	chess perft -suite
+func runPerft(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("perft", flag.ContinueOnError)
+	fs.SetOutput(out)
+	depth := fs.Int("depth", 5, "search depth in plies")
+	fen := fs.String("fen", StartFEN, "position to start from")
+	divide := fs.Bool("divide", false, "print the node count below each root move")
+	suite := fs.Bool("suite", false, "run the standard test positions and compare with the published counts")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *suite {
+		return runPerftSuite(out)
+	}
+	// Perft only stops at depth 0
+	if *depth < 0 {
+		return fmt.Errorf("perft: invalid depth %d, must not be negative", *depth)
+	}
+
+	pos, err := ParseFEN(*fen)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var nodes uint64
+	if *divide {
+		counts := pos.PerftDivide(*depth)
+		moves := make([]string, 0, len(counts))
+		for m := range counts {
+			moves = append(moves, m)
+		}
+		sort.Strings(moves)
+		for _, m := range moves {
+			fmt.Fprintf(out, "%s: %d\n", m, counts[m])
+			nodes += counts[m]
+		}
+		fmt.Fprintln(out)
+	} else {
+		nodes = pos.Perft(*depth)
+	}
+	elapsed := time.Since(start)
+
+	fmt.Fprintf(out, "Nodes searched: %d\n", nodes)
+	fmt.Fprintf(out, "Time: %v, %d nps\n", elapsed.Round(time.Millisecond), nodesPerSecond(nodes, elapsed))
+	return nil
+}
+
+// runPerftSuite checks every position of perftSuite and fails if a count does not match
+func runPerftSuite(out io.Writer) error {
+	failed := 0
+	for _, t := range perftSuite {
+		pos, err := ParseFEN(t.fen)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		nodes := pos.Perft(t.depth)
+		elapsed := time.Since(start)
+
+		status := "ok"
+		if nodes != t.nodes {
+			status = fmt.Sprintf("FAILED, expected %d", t.nodes)
+			failed++
+		}
+		fmt.Fprintf(out, "%-16s depth %d: %10d nodes %10d nps  %s\n",
+			t.name, t.depth, nodes, nodesPerSecond(nodes, elapsed), status)
+	}
+	if failed > 0 {
+		return fmt.Errorf("perft: %d of %d positions failed", failed, len(perftSuite))
+	}
+	return nil
+}
+
+// nodesPerSecond returns the search speed, guarding against a zero duration
+func nodesPerSecond(nodes uint64, elapsed time.Duration) uint64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return uint64(float64(nodes) / elapsed.Seconds())
+}
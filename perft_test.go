@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestPerftSuite(t *testing.T) {
+	for _, tt := range perftSuite {
+		t.Run(tt.name, func(t *testing.T) {
+			if testing.Short() && tt.nodes > 5000000 {
+				t.Skip("skipping the deep counts in short mode")
+			}
+			pos, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fen := pos.FEN()
+			if nodes := pos.Perft(tt.depth); nodes != tt.nodes {
+				t.Errorf("perft(%d) = %d, want %d", tt.depth, nodes, tt.nodes)
+			}
+			if got := pos.FEN(); got != fen {
+				t.Errorf("position changed during perft: %s", got)
+			}
+		})
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	pos := NewPosition()
+	counts := pos.PerftDivide(3)
+	if len(counts) != 20 {
+		t.Errorf("got %d root moves, want 20", len(counts))
+	}
+	var total uint64
+	for _, n := range counts {
+		total += n
+	}
+	if total != 8902 {
+		t.Errorf("divide counts add up to %d, want 8902", total)
+	}
+	if counts["g1f3"] != 440 {
+		t.Errorf("g1f3: %d nodes, want 440", counts["g1f3"])
+	}
+}
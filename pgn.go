@@ -0,0 +1,687 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sevenTagRoster lists the tags every PGN game has, in the order they are written
+var sevenTagRoster = []Tag{
+	{"Event", "?"},
+	{"Site", "?"},
+	{"Date", "????.??.??"},
+	{"Round", "?"},
+	{"White", "?"},
+	{"Black", "?"},
+	{"Result", "*"},
+}
+
+// pgnLineWidth is the width of the PGN output; lines end before column 80 as the export format requires
+const pgnLineWidth = 80
+
+// suffixNAGs maps move suffix annotations to their numeric annotation glyphs
+var suffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// Tag is a PGN tag pair
+type Tag struct {
+	Name, Value string
+}
+
+// Game is a chess game with its tags and a tree of moves and variations.
+type Game struct {
+	Tags []Tag     // in the order read or set
+	Root *GameNode // the start position; its first child is the first move of the game
+}
+
+// GameNode is a move in the game tree. The first child continues the line, further children
+// are variations replacing it.
+type GameNode struct {
+	Move         Move // NoMove for the root
+	NAGs         []int
+	StartComment string // comment before the move, at the start of a variation
+	Comment      string // comment after the move; the root's comment precedes the game
+	Parent       *GameNode
+	Children     []*GameNode
+}
+
+// NewGame returns an empty game starting from start, with the seven-tag roster set to unknown.
+func NewGame(start *Position) *Game {
+	g := &Game{Tags: append([]Tag(nil), sevenTagRoster...), Root: &GameNode{}}
+	if fen := start.FEN(); fen != StartFEN {
+		g.SetTag("SetUp", "1")
+		g.SetTag("FEN", fen)
+	}
+	return g
+}
+
+// Tag returns the value of a tag, or "" if the game does not have it
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// SetTag sets the value of a tag, adding the tag if the game does not have it yet
+func (g *Game) SetTag(name, value string) {
+	for i, t := range g.Tags {
+		if t.Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{name, value})
+}
+
+// StartPosition returns the position the game starts from, given by the FEN tag or the standard one
+func (g *Game) StartPosition() (Position, error) {
+	if fen := g.Tag("FEN"); fen != "" {
+		return ParseFEN(fen)
+	}
+	return NewPosition(), nil
+}
+
+// MainLine returns the moves of the game without the variations
+func (g *Game) MainLine() []Move {
+	return g.End().Line()
+}
+
+// End returns the last node of the main line
+func (g *Game) End() *GameNode {
+	n := g.Root
+	for len(n.Children) > 0 {
+		n = n.Children[0]
+	}
+	return n
+}
+
+// Line returns the moves leading from the start position to this node
+func (n *GameNode) Line() []Move {
+	var moves []Move
+	for ; n.Parent != nil; n = n.Parent {
+		moves = append(moves, n.Move)
+	}
+	for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+		moves[i], moves[j] = moves[j], moves[i]
+	}
+	return moves
+}
+
+// AddMove returns the child node for m, adding it as the last variation if there is none yet
+func (n *GameNode) AddMove(m Move) *GameNode {
+	for _, c := range n.Children {
+		if c.Move == m {
+			return c
+		}
+	}
+	c := &GameNode{Move: m, Parent: n}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// isResult reports whether s is a game termination marker
+func isResult(s string) bool {
+	return s == "1-0" || s == "0-1" || s == "1/2-1/2" || s == "*"
+}
+
+// PGN token kinds
+const (
+	tokEOF = iota
+	tokSymbol
+	tokString
+	tokNAG
+	tokComment
+	tokPeriod
+	tokOpenBracket
+	tokCloseBracket
+	tokOpenParen
+	tokCloseParen
+)
+
+// pgnToken is a token of PGN text
+type pgnToken struct {
+	kind int
+	text string
+	line int
+}
+
+// PGNReader reads games from PGN text one at a time, so files of any size can be processed.
+type PGNReader struct {
+	r      *bufio.Reader
+	line   int
+	bol    bool // the next byte starts a line
+	first  bool // the last byte read started a line, where % escapes the line
+	peeked *pgnToken
+	games  int
+}
+
+// NewPGNReader returns a reader for the PGN games in r.
+func NewPGNReader(r io.Reader) *PGNReader {
+	return &PGNReader{r: bufio.NewReader(r), line: 1, bol: true}
+}
+
+// Next reads the next game. It returns io.EOF when there are no more games. After an error in
+// a game the rest of that game is skipped, so reading can continue with the next one.
+func (pr *PGNReader) Next() (*Game, error) {
+	tok, err := pr.token()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind == tokEOF {
+		return nil, io.EOF
+	}
+	pr.unread(tok)
+	pr.games++
+
+	g := &Game{Root: &GameNode{}}
+	if err := pr.readTags(g); err != nil {
+		pr.skipGame(false)
+		return nil, err
+	}
+	if err := pr.readMoves(g); err != nil {
+		pr.skipGame(true)
+		return nil, err
+	}
+	return g, nil
+}
+
+// errorf returns an error with the game number and line
+func (pr *PGNReader) errorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("pgn: game %d, line %d: %s", pr.games, line, fmt.Sprintf(format, args...))
+}
+
+// readTags reads the tag pair section
+func (pr *PGNReader) readTags(g *Game) error {
+	for {
+		tok, err := pr.token()
+		if err != nil {
+			return err
+		}
+		if tok.kind != tokOpenBracket {
+			pr.unread(tok)
+			return nil
+		}
+		name, err := pr.token()
+		if err != nil {
+			return err
+		}
+		value, err := pr.token()
+		if err != nil {
+			return err
+		}
+		end, err := pr.token()
+		if err != nil {
+			return err
+		}
+		if name.kind != tokSymbol || value.kind != tokString || end.kind != tokCloseBracket {
+			pr.skipTag(end)
+			return pr.errorf(tok.line, "malformed tag pair")
+		}
+		g.SetTag(name.text, value.text)
+	}
+}
+
+// readMoves reads the movetext section up to and including the result
+func (pr *PGNReader) readMoves(g *Game) error {
+	pos, err := g.StartPosition()
+	if err != nil {
+		return pr.errorf(pr.line, "%v", err)
+	}
+
+	// variations restore the position and node they started from
+	type variation struct {
+		pos  Position
+		node *GameNode
+	}
+	var stack []variation
+	node := g.Root
+	startComment, inStart := "", false // inStart is set until the first move of a variation
+
+	for {
+		tok, err := pr.token()
+		if err != nil {
+			return err
+		}
+		switch tok.kind {
+		case tokEOF, tokOpenBracket:
+			// a missing result ends the game as well
+			pr.unread(tok)
+			if len(stack) > 0 {
+				return pr.errorf(tok.line, "unterminated variation")
+			}
+			if g.Tag("Result") == "" {
+				g.SetTag("Result", "*")
+			}
+			return nil
+		case tokPeriod:
+		case tokNAG:
+			n, err := strconv.Atoi(tok.text)
+			if err != nil {
+				return pr.errorf(tok.line, "invalid NAG $%s", tok.text)
+			}
+			node.NAGs = append(node.NAGs, n)
+		case tokComment:
+			if inStart {
+				startComment = joinComment(startComment, tok.text)
+			} else {
+				node.Comment = joinComment(node.Comment, tok.text)
+			}
+		case tokOpenParen:
+			if node == g.Root {
+				return pr.errorf(tok.line, "variation before the first move")
+			}
+			stack = append(stack, variation{pos.Clone(), node})
+			pos.UnmakeMove()
+			node = node.Parent
+			inStart = true
+		case tokCloseParen:
+			if len(stack) == 0 {
+				return pr.errorf(tok.line, "unexpected )")
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			pos, node = top.pos, top.node
+			inStart = false
+		case tokSymbol:
+			if isResult(tok.text) {
+				if len(stack) > 0 {
+					return pr.errorf(tok.line, "result %s inside a variation", tok.text)
+				}
+				g.SetTag("Result", tok.text)
+				return nil
+			}
+			if isMoveNumber(tok.text) || tok.text == "e.p." {
+				continue
+			}
+
+			san := strings.TrimRight(tok.text, "!?")
+			m, err := pos.ParseSAN(san)
+			if err != nil {
+				return pr.errorf(tok.line, "%v", err)
+			}
+			node = node.AddMove(m)
+			if inStart {
+				node.StartComment, startComment, inStart = startComment, "", false
+			}
+			if suffix := tok.text[len(san):]; suffix != "" {
+				nag, ok := suffixNAGs[suffix]
+				if !ok {
+					return pr.errorf(tok.line, "invalid move suffix %q", suffix)
+				}
+				node.NAGs = append(node.NAGs, nag)
+			}
+			pos.MakeMove(m)
+		default:
+			return pr.errorf(tok.line, "unexpected %q", tok.text)
+		}
+	}
+}
+
+// skipTag discards the rest of a malformed tag pair whose last token read was tok, up to
+// its closing bracket. A new tag pair starting instead is left for the caller.
+func (pr *PGNReader) skipTag(tok pgnToken) {
+	for {
+		switch tok.kind {
+		case tokCloseBracket, tokEOF:
+			return
+		case tokOpenBracket:
+			pr.unread(tok)
+			return
+		}
+		var err error
+		if tok, err = pr.token(); err != nil {
+			return
+		}
+	}
+}
+
+// skipGame discards the rest of the current game after an error: the remaining tag pairs,
+// unless the error was in the movetext already, and the movetext up to the result. A tag
+// pair after the movetext starts the next game, which has lost its predecessor's result.
+func (pr *PGNReader) skipGame(inMovetext bool) {
+	inTag := false
+	for {
+		tok, err := pr.token()
+		if err != nil || tok.kind == tokEOF || !inTag && tok.kind == tokSymbol && isResult(tok.text) {
+			return
+		}
+		switch {
+		case tok.kind == tokOpenBracket && inMovetext:
+			pr.unread(tok)
+			return
+		case tok.kind == tokOpenBracket:
+			inTag = true
+		case tok.kind == tokCloseBracket:
+			inTag = false
+		case !inTag:
+			inMovetext = true
+		}
+	}
+}
+
+// isMoveNumber reports whether s consists of digits only, as in "12." or "12..."
+func isMoveNumber(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// joinComment appends a further comment to the comment of a move
+func joinComment(comment, more string) string {
+	more = strings.TrimSpace(more)
+	if comment == "" {
+		return more
+	}
+	return comment + " " + more
+}
+
+// unread pushes a token back, the next call to token returns it again
+func (pr *PGNReader) unread(tok pgnToken) {
+	pr.peeked = &tok
+}
+
+// token returns the next token of the input
+func (pr *PGNReader) token() (pgnToken, error) {
+	if pr.peeked != nil {
+		tok := *pr.peeked
+		pr.peeked = nil
+		return tok, nil
+	}
+
+	for {
+		c, err := pr.readByte()
+		if err == io.EOF {
+			return pgnToken{kind: tokEOF, line: pr.line}, nil
+		}
+		if err != nil {
+			return pgnToken{}, err
+		}
+		line := pr.line
+
+		switch {
+		case c == '\n' || c == ' ' || c == '\t' || c == '\r':
+		case c == '%' && pr.first:
+			// escaped line, for use by other programs
+			if _, err := pr.readUntil('\n'); err != nil {
+				return pgnToken{kind: tokEOF, line: line}, nil
+			}
+		case c == ';':
+			text, _ := pr.readUntil('\n')
+			return pgnToken{tokComment, strings.TrimSuffix(text, "\n"), line}, nil
+		case c == '{':
+			text, err := pr.readUntil('}')
+			if err != nil {
+				return pgnToken{}, pr.errorf(line, "unterminated comment")
+			}
+			return pgnToken{tokComment, strings.TrimSuffix(text, "}"), line}, nil
+		case c == '"':
+			return pr.readString(line)
+		case c == '$':
+			return pgnToken{tokNAG, pr.readWhile(isDigit), line}, nil
+		case c == '.':
+			return pgnToken{tokPeriod, ".", line}, nil
+		case c == '[':
+			return pgnToken{tokOpenBracket, "[", line}, nil
+		case c == ']':
+			return pgnToken{tokCloseBracket, "]", line}, nil
+		case c == '(':
+			return pgnToken{tokOpenParen, "(", line}, nil
+		case c == ')':
+			return pgnToken{tokCloseParen, ")", line}, nil
+		case c == '*':
+			return pgnToken{tokSymbol, "*", line}, nil
+		case isSymbolChar(c):
+			text := string(c) + pr.readWhile(isSymbolChar)
+			// the en passant marker of "exd6 e.p." contains periods
+			if next, _ := pr.r.Peek(3); text == "e" && string(next) == ".p." {
+				pr.r.Discard(3)
+				text = "e.p."
+			}
+			return pgnToken{tokSymbol, text, line}, nil
+		default:
+			return pgnToken{}, pr.errorf(line, "unexpected character %q", c)
+		}
+	}
+}
+
+// readByte reads one byte and keeps track of lines
+func (pr *PGNReader) readByte() (byte, error) {
+	c, err := pr.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	pr.first = pr.bol
+	pr.bol = c == '\n'
+	if c == '\n' {
+		pr.line++
+	}
+	return c, nil
+}
+
+// readUntil reads up to and including the delimiter
+func (pr *PGNReader) readUntil(delim byte) (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := pr.readByte()
+		if err != nil {
+			return sb.String(), err
+		}
+		sb.WriteByte(c)
+		if c == delim {
+			return sb.String(), nil
+		}
+	}
+}
+
+// readWhile reads the bytes that satisfy ok
+func (pr *PGNReader) readWhile(ok func(byte) bool) string {
+	var sb strings.Builder
+	for {
+		c, err := pr.r.ReadByte()
+		if err != nil {
+			return sb.String()
+		}
+		if !ok(c) {
+			pr.r.UnreadByte()
+			return sb.String()
+		}
+		pr.bol = false
+		sb.WriteByte(c)
+	}
+}
+
+// readString reads a quoted tag value; \" and \\ are escapes
+func (pr *PGNReader) readString(line int) (pgnToken, error) {
+	var sb strings.Builder
+	for {
+		c, err := pr.readByte()
+		if err != nil || c == '\n' {
+			return pgnToken{}, pr.errorf(line, "unterminated string")
+		}
+		switch c {
+		case '"':
+			return pgnToken{tokString, sb.String(), line}, nil
+		case '\\':
+			if c, err = pr.readByte(); err != nil {
+				return pgnToken{}, pr.errorf(line, "unterminated string")
+			}
+		}
+		sb.WriteByte(c)
+	}
+}
+
+// isDigit reports whether c is a decimal digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isSymbolChar reports whether c can continue a symbol token: moves, move numbers, results
+// and tag names. Suffix annotations are included and split off by the parser.
+func isSymbolChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) ||
+		strings.IndexByte("_+#=:-/!?", c) >= 0
+}
+
+// WritePGN writes the game in PGN export format: the seven-tag roster first, then the other
+// tags, then the movetext with variations and comments, wrapped to fit 80 columns.
+func (g *Game) WritePGN(w io.Writer) error {
+	pos, err := g.StartPosition()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	result := g.Tag("Result")
+	if !isResult(result) {
+		result = "*"
+	}
+	for _, t := range sevenTagRoster {
+		value := g.Tag(t.Name)
+		if value == "" {
+			value = t.Value
+		}
+		if t.Name == "Result" {
+			value = result
+		}
+		writeTag(bw, t.Name, value)
+	}
+	for _, t := range g.Tags {
+		if !isRosterTag(t.Name) {
+			writeTag(bw, t.Name, t.Value)
+		}
+	}
+	bw.WriteString("\n")
+
+	pw := &pgnWriter{w: bw, needNumber: true}
+	pw.comment(g.Root.Comment)
+	pw.moves(&pos, g.Root)
+	pw.word(result)
+	pw.flush()
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// isRosterTag reports whether name is one of the seven-tag roster
+func isRosterTag(name string) bool {
+	for _, t := range sevenTagRoster {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTag writes a tag pair line, escaping quotes and backslashes in the value
+func writeTag(w *bufio.Writer, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(w, "[%s \"%s\"]\n", name, value)
+}
+
+// pgnWriter writes movetext and wraps its lines
+type pgnWriter struct {
+	w          *bufio.Writer
+	line       []byte
+	prefix     string // written in front of the next word, like the parenthesis opening a variation
+	needNumber bool   // the next black move needs its move number, after a comment or variation
+}
+
+// moves writes the line continuing after node and its variations; pos is the position at node
+func (pw *pgnWriter) moves(pos *Position, node *GameNode) {
+	made := 0
+	for len(node.Children) > 0 {
+		main := node.Children[0]
+		pw.move(pos, main)
+		for _, v := range node.Children[1:] {
+			pw.prefix = "("
+			pw.comment(v.StartComment)
+			pw.needNumber = true
+			pw.move(pos, v)
+			pos.MakeMove(v.Move)
+			pw.moves(pos, v)
+			pos.UnmakeMove()
+			pw.attach(")")
+			pw.needNumber = true
+		}
+		pos.MakeMove(main.Move)
+		made++
+		node = main
+	}
+	for ; made > 0; made-- {
+		pos.UnmakeMove()
+	}
+}
+
+// move writes a move with its number, annotations and comment; pos is the position before it
+func (pw *pgnWriter) move(pos *Position, n *GameNode) {
+	if pos.side == White {
+		pw.word(strconv.Itoa(pos.fullmoveNumber) + ".")
+	} else if pw.needNumber {
+		pw.word(strconv.Itoa(pos.fullmoveNumber) + "...")
+	}
+	pw.needNumber = false
+	pw.word(pos.SAN(n.Move))
+	for _, nag := range n.NAGs {
+		pw.word("$" + strconv.Itoa(nag))
+	}
+	pw.comment(n.Comment)
+}
+
+// comment writes a comment in braces, broken into words so it can be wrapped
+func (pw *pgnWriter) comment(text string) {
+	// a comment cannot contain its closing brace
+	words := strings.Fields(strings.ReplaceAll(text, "}", ")"))
+	if len(words) == 0 {
+		return
+	}
+	words[0] = "{" + words[0]
+	words[len(words)-1] += "}"
+	for _, w := range words {
+		pw.word(w)
+	}
+	pw.needNumber = true
+}
+
+// word adds a word to the line, separated by a space
+func (pw *pgnWriter) word(s string) {
+	s, pw.prefix = pw.prefix+s, ""
+	pw.add(s, len(pw.line) > 0)
+}
+
+// attach adds a word to the line directly after the last one
+func (pw *pgnWriter) attach(s string) {
+	pw.add(s, false)
+}
+
+// add appends s to the line, wrapping before it if the line would reach column 80
+func (pw *pgnWriter) add(s string, space bool) {
+	n := len(pw.line) + len(s)
+	if space {
+		n++
+	}
+	if len(pw.line) > 0 && n >= pgnLineWidth {
+		pw.flush()
+		space = false
+	}
+	if space {
+		pw.line = append(pw.line, ' ')
+	}
+	pw.line = append(pw.line, s...)
+}
+
+// flush writes the current line
+func (pw *pgnWriter) flush() {
+	if len(pw.line) > 0 {
+		pw.w.Write(pw.line)
+		pw.w.WriteByte('\n')
+		pw.line = pw.line[:0]
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPGN = `% an escaped line is ignored
+[Event "Test \"quoted\""]
+[Site "?"]
+[Round "1"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+[Date "2024.01.01"]
+[Annotator "X"]
+
+{Opening comment} 1. e4 e5 2. Nf3! $1 ; rest of line comment
+Nc6 (2... d6 {Philidor} 3. d4 (3. Bc4 Be7) exd4) 3. Bb5 a6?! {Morphy} 4. Ba4 Nf6 5. O-O 1-0
+
+[Event "Second"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 1"]
+[SetUp "1"]
+
+1... Kd7 2. e4 *
+`
+
+// testPGNExport is testPGN as WritePGN writes it
+const testPGNExport = `[Event "Test \"quoted\""]
+[Site "?"]
+[Date "2024.01.01"]
+[Round "1"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+[Annotator "X"]
+
+{Opening comment} 1. e4 e5 2. Nf3 $1 $1 {rest of line comment} 2... Nc6 (2...
+d6 {Philidor} 3. d4 (3. Bc4 Be7) 3... exd4) 3. Bb5 a6 $6 {Morphy} 4. Ba4 Nf6 5.
+O-O 1-0
+
+[Event "Second"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 1"]
+[SetUp "1"]
+
+1... Kd7 2. e4 *
+
+`
+
+// readGames reads all games of a PGN text
+func readGames(t *testing.T, text string) []*Game {
+	t.Helper()
+	var games []*Game
+	r := NewPGNReader(strings.NewReader(text))
+	for {
+		g, err := r.Next()
+		if err == io.EOF {
+			return games
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		games = append(games, g)
+	}
+}
+
+// moveStrings returns moves in coordinate notation
+func moveStrings(moves []Move) []string {
+	s := make([]string, len(moves))
+	for i, m := range moves {
+		s[i] = m.String()
+	}
+	return s
+}
+
+func TestPGNRead(t *testing.T) {
+	games := readGames(t, testPGN)
+	if len(games) != 2 {
+		t.Fatalf("read %d games, want 2", len(games))
+	}
+
+	g := games[0]
+	for name, want := range map[string]string{
+		"Event": `Test "quoted"`, "Result": "1-0", "Annotator": "X", "Opening": "",
+	} {
+		if got := g.Tag(name); got != want {
+			t.Errorf("tag %s = %q, want %q", name, got, want)
+		}
+	}
+	wantLine := []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "a7a6", "b5a4", "g8f6", "e1g1"}
+	if got := moveStrings(g.MainLine()); !reflect.DeepEqual(got, wantLine) {
+		t.Errorf("main line = %v, want %v", got, wantLine)
+	}
+	if g.Root.Comment != "Opening comment" {
+		t.Errorf("game comment = %q", g.Root.Comment)
+	}
+
+	nf3 := g.Root.Children[0].Children[0].Children[0]
+	if !reflect.DeepEqual(nf3.NAGs, []int{1, 1}) || nf3.Comment != "rest of line comment" {
+		t.Errorf("Nf3: NAGs %v, comment %q", nf3.NAGs, nf3.Comment)
+	}
+	if len(nf3.Children) != 2 {
+		t.Fatalf("Nf3 has %d continuations, want the main line and one variation", len(nf3.Children))
+	}
+	d6 := nf3.Children[1]
+	if d6.Comment != "Philidor" {
+		t.Errorf("d6 comment = %q", d6.Comment)
+	}
+	wantVariation := []string{"e2e4", "e7e5", "g1f3", "d7d6", "f1c4", "f8e7"}
+	if got := moveStrings(d6.Children[1].Children[0].Line()); !reflect.DeepEqual(got, wantVariation) {
+		t.Errorf("nested variation = %v, want %v", got, wantVariation)
+	}
+	if a6 := g.End().Parent.Parent.Parent; !reflect.DeepEqual(a6.NAGs, []int{6}) || a6.Comment != "Morphy" {
+		t.Errorf("a6: NAGs %v, comment %q", a6.NAGs, a6.Comment)
+	}
+
+	start, err := games[1].StartPosition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start.side != Black || games[1].Tag("Result") != "*" {
+		t.Errorf("second game: side %d, result %q", start.side, games[1].Tag("Result"))
+	}
+}
+
+func TestPGNWrite(t *testing.T) {
+	var sb strings.Builder
+	for _, g := range readGames(t, testPGN) {
+		if err := g.WritePGN(&sb); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sb.String() != testPGNExport {
+		t.Errorf("WritePGN wrote\n%s\nwant\n%s", sb.String(), testPGNExport)
+	}
+
+	// the export format reads back to the same games
+	var again strings.Builder
+	for _, g := range readGames(t, sb.String()) {
+		if err := g.WritePGN(&again); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if again.String() != sb.String() {
+		t.Errorf("round trip changed the games:\n%s", again.String())
+	}
+}
+
+func TestPGNWriteWrapsLines(t *testing.T) {
+	pos := NewPosition()
+	g := NewGame(&pos)
+	node := g.Root
+	for i := 0; i < 40; i++ {
+		for _, s := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+			m, err := pos.ParseMove(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			node = node.AddMove(m)
+			node.Comment = "a comment to fill the line"
+			pos.MakeMove(m)
+		}
+	}
+	var sb strings.Builder
+	if err := g.WritePGN(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if len(line) >= pgnLineWidth {
+			t.Errorf("line of %d characters: %s", len(line), line)
+		}
+	}
+	games := readGames(t, sb.String())
+	if len(games) != 1 || len(games[0].MainLine()) != 160 {
+		t.Errorf("wrapped game did not read back")
+	}
+}
+
+func TestPGNReadErrors(t *testing.T) {
+	tests := []struct {
+		name, game, err string
+	}{
+		{"illegal move", "[Event \"broken\"]\n\n1. e5 *", `illegal move "e5"`},
+		{"unterminated variation", "[Event \"broken\"]\n\n1. e4 (1. d4", "unterminated variation"},
+		{"unexpected paren", "[Event \"broken\"]\n\n1. e4 ) *", "unexpected )"},
+		{"variation first", "[Event \"broken\"]\n\n( 1. e4 ) *", "variation before the first move"},
+		{"result in variation", "[Event \"broken\"]\n\n1. e4 (1. d4 1-0) *", "inside a variation"},
+		{"malformed tag pair", "[Event \"broken\"]\n[Site \"v\" extra]\n[White \"x\"]\n\n1. e4 *",
+			"malformed tag pair"},
+		{"tag pair without value", "[Event \"broken\"]\n[Site]\n[White \"x\"]\n\n1. e4 *", "malformed tag pair"},
+		{"unclosed tag pair", "[Event \"broken\"]\n[Site \"v\"\n[White \"x\"]\n\n1. e4 *", "malformed tag pair"},
+	}
+	for _, tt := range tests {
+		// a broken game is skipped and the next one is read
+		text := tt.game + "\n\n[Event \"next\"]\n\n1. d4 *\n"
+		r := NewPGNReader(strings.NewReader(text))
+		if _, err := r.Next(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+		g, err := r.Next()
+		if err != nil || g.Tag("Event") != "next" {
+			t.Errorf("%s: game after the error: %v, %v", tt.name, g, err)
+		}
+		if _, err := r.Next(); err != io.EOF {
+			t.Errorf("%s: got %v at the end, want io.EOF", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+// mobility scores as opening and endgame pairs, indexed by the number of safe squares a piece
+// can move to: empty or enemy occupied squares not attacked by an enemy pawn
+var (
+	knightMobility = [9][2]int{
+		{-30, -40}, {-15, -24}, {-5, -12}, {3, -3}, {8, 3}, {12, 7}, {15, 10}, {17, 12}, {18, 14},
+	}
+	bishopMobility = [14][2]int{
+		{-30, -40}, {-17, -25}, {-7, -12}, {2, -2}, {9, 7}, {14, 14}, {19, 19},
+		{23, 24}, {26, 28}, {28, 31}, {31, 33}, {32, 36}, {34, 37}, {35, 39},
+	}
+	rookMobility = [15][2]int{
+		{-20, -40}, {-14, -26}, {-9, -14}, {-4, -5}, {-1, 4}, {3, 11}, {5, 17}, {8, 22},
+		{9, 26}, {11, 30}, {12, 33}, {14, 36}, {15, 38}, {15, 40}, {16, 41},
+	}
+	queenMobility = [28][2]int{
+		{-20, -30}, {-15, -22}, {-10, -15}, {-6, -9}, {-2, -3}, {1, 2}, {4, 6},
+		{6, 11}, {8, 14}, {10, 17}, {12, 20}, {14, 23}, {15, 25}, {16, 27},
+		{17, 29}, {18, 31}, {19, 32}, {20, 34}, {20, 35}, {21, 36}, {21, 37},
+		{22, 38}, {22, 38}, {22, 39}, {23, 40}, {23, 40}, {23, 41}, {23, 41},
+	}
+)
+
+// piece activity scores as opening and endgame pairs
+var (
+	knightOutpostBonus = [2]int{25, 15} // for a knight on an outpost, see isOutpost
+	bishopOutpostBonus = [2]int{12, 6}
+	rookOpenFileBonus  = [2]int{25, 10} // no pawns on the file
+	rookHalfOpenBonus  = [2]int{12, 6}  // no own pawns on the file
+	rookSeventhBonus   = [2]int{20, 30} // when it hems in the enemy king or attacks pawns there
+	bishopPairBonus    = [2]int{30, 50}
+	badBishopPenalty   = [2]int{-3, -5} // for every own pawn on a square of the bishop's color
+
+	// a bishop that took the a7 or h7 pawn and is shut in by a pawn on b6 or g6
+	trappedBishopPenalty = [2]int{-100, -100}
+	// a rook next to the king on the back rank, shut in because the king walked or castling is gone
+	trappedRookPenalty = [2]int{-40, -10}
+)
+
+// trappedRookMobility is the mobility at or below which a rook beside its king counts as trapped
+const trappedRookMobility = 3
+
+// pieceMobility returns the opening and endgame mobility score of the knights, bishops, rooks
+// and queens of color
+func (p *Position) pieceMobility(color int) [2]int {
+	var score [2]int
+	area := ^p.colorBB[color] &^ pawnAttacksBB(color^1, p.pieceBB[color^1][Pawn])
+	occupied := p.occupied()
+	for piece := Knight; piece <= Queen; piece++ {
+		for b := p.pieceBB[color][piece]; b != 0; {
+			term := mobilityScore(piece, popCount(pieceAttacks(piece, popLSB(&b), occupied)&area))
+			score[0] += term[0]
+			score[1] += term[1]
+		}
+	}
+	return score
+}
+
+// mobilityScore looks up the score of a piece with the given number of safe squares
+func mobilityScore(piece, count int) [2]int {
+	switch piece {
+	case Knight:
+		return knightMobility[count]
+	case Bishop:
+		return bishopMobility[count]
+	case Rook:
+		return rookMobility[count]
+	default:
+		return queenMobility[count]
+	}
+}
+
+// pieceActivity returns the opening and endgame score for the placement of color's minor
+// pieces and rooks: outposts, rooks on open files and the seventh rank, the bishop pair, bad
+// bishops and trapped pieces
+func (p *Position) pieceActivity(color int) [2]int {
+	var score [2]int
+	add := func(term [2]int, n int) {
+		score[0] += term[0] * n
+		score[1] += term[1] * n
+	}
+
+	own, enemy := p.pieceBB[color][Pawn], p.pieceBB[color^1][Pawn]
+	for b := p.pieceBB[color][Knight]; b != 0; {
+		if p.isOutpost(color, popLSB(&b)) {
+			add(knightOutpostBonus, 1)
+		}
+	}
+
+	bishops := p.pieceBB[color][Bishop]
+	if bishops&darkSquares != 0 && bishops&^darkSquares != 0 {
+		add(bishopPairBonus, 1)
+	}
+	for b := bishops; b != 0; {
+		sq := popLSB(&b)
+		if p.isOutpost(color, sq) {
+			add(bishopOutpostBonus, 1)
+		}
+		sameColor := darkSquares
+		if squareBB(sq)&darkSquares == 0 {
+			sameColor = ^darkSquares
+		}
+		add(badBishopPenalty, popCount(own&sameColor))
+		if p.isTrappedBishop(color, sq) {
+			add(trappedBishopPenalty, 1)
+		}
+	}
+
+	occupied := p.occupied()
+	enemyKing := p.kingSquare(color ^ 1)
+	seventh, eighth := 6, 7
+	if color == Black {
+		seventh, eighth = 1, 0
+	}
+	for b := p.pieceBB[color][Rook]; b != 0; {
+		sq := popLSB(&b)
+		file := fileMask[squareFile[sq]]
+		switch {
+		case (own|enemy)&file == 0:
+			add(rookOpenFileBonus, 1)
+		case own&file == 0:
+			add(rookHalfOpenBonus, 1)
+		}
+		if squareRank[sq] == seventh && (squareRank[enemyKing] == eighth || enemy&rankMask(seventh) != 0) {
+			add(rookSeventhBonus, 1)
+		}
+		if p.isTrappedRook(color, sq, occupied) {
+			add(trappedRookPenalty, 1)
+		}
+	}
+	return score
+}
+
+// isOutpost reports whether a piece of color on sq stands on an outpost: on the fourth to
+// sixth rank from color's side, defended by an own pawn, and out of reach of the enemy pawns
+func (p *Position) isOutpost(color, sq int) bool {
+	rank := squareRank[sq]
+	if color == Black {
+		rank = 7 - rank
+	}
+	if rank < 3 || rank > 5 || pawnAttacks[color^1][sq]&p.pieceBB[color][Pawn] == 0 {
+		return false
+	}
+	reach := adjacentFilesMask[squareFile[sq]] & forwardRanksMask[color][squareRank[sq]]
+	return p.pieceBB[color^1][Pawn]&reach == 0
+}
+
+// isTrappedBishop reports whether a bishop of color on sq took a rook's pawn on the seventh
+// rank (a7, h7, a2, h2) and an enemy pawn has closed its way back on b6, g6, b3 or g3
+func (p *Position) isTrappedBishop(color, sq int) bool {
+	relative := sq
+	if color == Black {
+		relative = flipSquare[sq]
+	}
+	var blocker int
+	switch Square(relative) {
+	case A7:
+		blocker = int(B6)
+	case H7:
+		blocker = int(G6)
+	default:
+		return false
+	}
+	if color == Black {
+		blocker = flipSquare[blocker]
+	}
+	return p.pieceBB[color^1][Pawn]&squareBB(blocker) != 0
+}
+
+// isTrappedRook reports whether a rook of color on sq is boxed in on the back rank between
+// its king and the corner, with hardly any squares to go to and no castling right left to free it
+func (p *Position) isTrappedRook(color, sq int, occupied uint64) bool {
+	king := p.kingSquare(color)
+	backRank := 0
+	if color == Black {
+		backRank = 7
+	}
+	if squareRank[sq] != backRank || squareRank[king] != backRank {
+		return false
+	}
+	rookFile, kingFile := squareFile[sq], squareFile[king]
+	var right int // the castling right that would still free the rook
+	switch {
+	case kingFile >= 4 && rookFile > kingFile:
+		right = castleWhiteKingSide
+	case kingFile < 4 && rookFile < kingFile:
+		right = castleWhiteQueenSide
+	default:
+		return false
+	}
+	if color == Black {
+		right <<= 2
+	}
+	return p.castling&right == 0 && popCount(rookAttacks(sq, occupied)&^p.colorBB[color]) <= trappedRookMobility
+}
+
+// pawnAttacksBB returns the squares the pawns of color attack
+func pawnAttacksBB(color int, pawns uint64) uint64 {
+	if color == White {
+		return (pawns&^fileMask[0])<<7 | (pawns&^fileMask[7])<<9
+	}
+	return (pawns&^fileMask[0])>>9 | (pawns&^fileMask[7])>>7
+}
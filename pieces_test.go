@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestPieceMobility(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		color int
+		want  [2]int
+	}{
+		{"knight in the corner", "4k3/8/8/8/8/8/8/N3K3 w - - 0 1", White, knightMobility[2]},
+		// b3 is covered by the pawn on a4
+		{"knight and enemy pawn", "4k3/8/8/8/p7/8/8/N3K3 w - - 0 1", White, knightMobility[1]},
+		// the own king blocks e1, the enemy pawn on a5 can be taken
+		{"rook", "4k3/8/8/p7/8/8/8/R3K3 w - - 0 1", White, rookMobility[7]},
+		{"black queen", "3qk3/8/8/8/8/8/8/4K3 b - - 0 1", Black, queenMobility[17]},
+	}
+	for _, tt := range tests {
+		pos, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := pos.pieceMobility(tt.color); got != tt.want {
+			t.Errorf("%s: pieceMobility = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPieceActivity(t *testing.T) {
+	times := func(term [2]int, n int) [2]int {
+		return [2]int{term[0] * n, term[1] * n}
+	}
+	tests := []struct {
+		name  string
+		fen   string
+		color int
+		want  [2]int
+	}{
+		{"knight outpost", "4k3/8/8/4N3/3P4/8/8/4K3 w - - 0 1", White, knightOutpostBonus},
+		{"outpost within pawn reach", "4k3/5p2/8/4N3/3P4/8/8/4K3 w - - 0 1", White, [2]int{}},
+		{"bishop pair", "4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", White, bishopPairBonus},
+		{"bad bishop", "4k3/8/8/8/8/8/1P1P4/2B1K3 w - - 0 1", White, times(badBishopPenalty, 2)},
+		{"trapped bishop", "4k3/B7/1p6/8/8/8/8/4K3 w - - 0 1", White, trappedBishopPenalty},
+		{"rook on an open file", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", White, rookOpenFileBonus},
+		{"rook on a half-open file", "4k3/p7/8/8/8/8/8/R3K3 w - - 0 1", White, rookHalfOpenBonus},
+		{"rook on the seventh", "4k3/R7/8/8/8/8/8/4K3 w - - 0 1", White,
+			[2]int{rookOpenFileBonus[0] + rookSeventhBonus[0], rookOpenFileBonus[1] + rookSeventhBonus[1]}},
+		{"trapped rook", "4k3/8/8/8/8/8/5PPP/5K1R w - - 0 1", White, trappedRookPenalty},
+		{"black rook", "r3k3/8/8/8/8/8/8/4K3 b - - 0 1", Black, rookOpenFileBonus},
+	}
+	for _, tt := range tests {
+		pos, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := pos.pieceActivity(tt.color); got != tt.want {
+			t.Errorf("%s: pieceActivity = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// playHelp lists the commands of the play mode
+const playHelp = `Enter moves in SAN (Nf3, exd5, O-O, e8=Q) or coordinate notation (g1f3, e7e8q).
+Commands:
+  undo                take back your last move and the engine's reply
+  new                 start a new game
+  flip                turn the board around
+  fen [FEN]           show the position as FEN, or set up a new position
+  go                  let the engine move now, you take the other side
+  hint                suggest a move
+  eval                break the static evaluation down into its terms
+  level <n|ns>        search n plies deep, or n seconds per move with an "s" suffix
+  save <file>         save the game as PGN
+  help                show this text
+  quit                leave`
+
+// playSession is an interactive game between a human at the terminal and the engine
+type playSession struct {
+	out     io.Writer
+	start   Position // position the game started from, for the PGN
+	pos     Position
+	human   int // side the human plays
+	flipped bool
+	limits  SearchLimits
+	over    bool // the game has ended, only commands are accepted
+}
+
+// runPlay implements the play command line mode:
+//
+//	chess play [-color white|black] [-depth n] [-time seconds]
+//
+// The human enters moves and commands on stdin and the engine replies.
+func runPlay(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	fs.SetOutput(out)
+	color := fs.String("color", "white", "side to play, white or black")
+	depth := fs.Int("depth", 0, "engine search depth in plies")
+	seconds := fs.Float64("time", 2, "engine time per move in seconds, unless -depth is given")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	s := &playSession{out: out, start: NewPosition(), human: White}
+	switch strings.ToLower(*color) {
+	case "white", "w":
+	case "black", "b":
+		s.human, s.flipped = Black, true
+	default:
+		return fmt.Errorf("play: invalid color %q", *color)
+	}
+	s.limits = SearchLimits{MoveTime: time.Duration(*seconds * float64(time.Second))}
+	if *depth > 0 {
+		s.limits = SearchLimits{Depth: *depth}
+	}
+	s.pos = s.start.Clone()
+
+	fmt.Fprintln(out, "Type help for the commands.")
+	s.showBoard()
+	s.engineTurn()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		if !s.handle(scanner.Text()) {
+			return nil
+		}
+	}
+}
+
+// handle executes one input line and returns false on quit
+func (s *playSession) handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+	args := fields[1:]
+
+	switch fields[0] {
+	case "quit", "exit":
+		return false
+	case "help":
+		fmt.Fprintln(s.out, playHelp)
+	case "new":
+		s.start = NewPosition()
+		s.reset()
+	case "undo":
+		s.undo()
+	case "flip":
+		s.flipped = !s.flipped
+		s.showBoard()
+	case "fen":
+		if len(args) == 0 {
+			fmt.Fprintln(s.out, s.pos.FEN())
+			return true
+		}
+		pos, err := ParseFEN(strings.Join(args, " "))
+		if err != nil {
+			fmt.Fprintln(s.out, err)
+			return true
+		}
+		s.start = pos
+		s.reset()
+	case "go":
+		s.human ^= 1
+		s.engineTurn()
+	case "hint":
+		if !s.over {
+			r := Search(context.Background(), &s.pos, s.limits, nil)
+			fmt.Fprintf(s.out, "Hint: %s (%s)\n", s.pos.SAN(r.BestMove), r.ScoreString())
+		}
+	case "eval":
+		s.pos.writeEvalTrace(s.out)
+	case "level":
+		s.setLevel(args)
+	case "save":
+		if len(args) == 0 {
+			fmt.Fprintln(s.out, "usage: save <file>")
+			return true
+		}
+		if err := s.save(args[0]); err != nil {
+			fmt.Fprintln(s.out, err)
+			return true
+		}
+		fmt.Fprintf(s.out, "Game saved to %s\n", args[0])
+	default:
+		s.userMove(fields[0])
+	}
+	return true
+}
+
+// reset starts a new game from s.start and lets the engine move if it is its turn
+func (s *playSession) reset() {
+	s.pos = s.start.Clone()
+	s.over = false
+	s.showBoard()
+	s.engineTurn()
+}
+
+// userMove plays the human's move and the engine's reply
+func (s *playSession) userMove(text string) {
+	if s.over {
+		fmt.Fprintln(s.out, "The game is over, type new or undo.")
+		return
+	}
+	if s.pos.side != s.human {
+		fmt.Fprintln(s.out, "It is not your turn, type go to let the engine move.")
+		return
+	}
+	m, err := s.pos.ParseMove(text)
+	if err != nil {
+		if m, err = s.pos.ParseSAN(text); err != nil {
+			fmt.Fprintln(s.out, err)
+			s.showLegalMoves(text)
+			return
+		}
+	}
+	s.pos.MakeMove(m)
+	s.showBoard()
+	if !s.checkGameOver() {
+		s.engineTurn()
+	}
+}
+
+// engineTurn lets the engine search and play a move if it is its turn
+func (s *playSession) engineTurn() {
+	if s.over || s.pos.side == s.human || s.checkGameOver() {
+		return
+	}
+	r := Search(context.Background(), &s.pos, s.limits, nil)
+	fmt.Fprintf(s.out, "Engine plays %s (%s, depth %d)\n", s.pos.SAN(r.BestMove), r.ScoreString(), r.Depth)
+	s.pos.MakeMove(r.BestMove)
+	s.showBoard()
+	s.checkGameOver()
+}
+
+// checkGameOver announces the result when the game has ended and reports whether it has
+func (s *playSession) checkGameOver() bool {
+	status := s.pos.GameStatus()
+	if status.Over() {
+		fmt.Fprintln(s.out, status)
+		s.over = true
+	}
+	return s.over
+}
+
+// undo takes back moves until it is the human's turn again, at least one
+func (s *playSession) undo() {
+	if len(s.pos.undo) == 0 {
+		fmt.Fprintln(s.out, "No moves to take back.")
+		return
+	}
+	s.pos.UnmakeMove()
+	for s.pos.side != s.human && len(s.pos.undo) > 0 {
+		s.pos.UnmakeMove()
+	}
+	s.over = false
+	s.showBoard()
+}
+
+// setLevel handles "level n" for a search depth and "level ns" for seconds per move
+func (s *playSession) setLevel(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(s.out, "usage: level <depth> or level <seconds>s")
+		return
+	}
+	if secs := strings.TrimSuffix(args[0], "s"); secs != args[0] {
+		t, err := strconv.ParseFloat(secs, 64)
+		if err != nil || t <= 0 {
+			fmt.Fprintf(s.out, "invalid time %q\n", args[0])
+			return
+		}
+		s.limits = SearchLimits{MoveTime: time.Duration(t * float64(time.Second))}
+		fmt.Fprintf(s.out, "Engine thinks %v per move\n", s.limits.MoveTime)
+		return
+	}
+	depth, err := strconv.Atoi(args[0])
+	if err != nil || depth <= 0 || depth >= maxPly {
+		fmt.Fprintf(s.out, "invalid depth %q\n", args[0])
+		return
+	}
+	s.limits = SearchLimits{Depth: depth}
+	fmt.Fprintf(s.out, "Engine searches %d plies\n", depth)
+}
+
+// showBoard draws the board and whose turn it is
+func (s *playSession) showBoard() {
+	fmt.Fprintln(s.out)
+	s.pos.writeBoard(s.out, s.flipped)
+	side := "White"
+	if s.pos.side == Black {
+		side = "Black"
+	}
+	fmt.Fprintf(s.out, "%s to move\n", side)
+}
+
+// showLegalMoves lists the legal moves of the piece the rejected move text refers to:
+// the from square of a coordinate move, or all pieces of the type given by a SAN move
+func (s *playSession) showLegalMoves(text string) {
+	targets := make(map[int][]int)
+	for _, m := range s.pos.LegalMoves() {
+		targets[m.From()] = appendTarget(targets[m.From()], m.To())
+	}
+
+	var squares []int
+	if from, err := AlgebraicToIndex(text[:minInt(2, len(text))]); err == nil && len(text) >= 4 {
+		squares = []int{from}
+	} else {
+		piece := Pawn
+		if i := strings.IndexByte(pieceLetters, text[0]); i > 0 {
+			piece = i
+		}
+		for sq := range targets {
+			if s.pos.pieces[sq] == piece && (piece != Pawn || text[0] == 'a'+byte(squareFile[sq])) {
+				squares = append(squares, sq)
+			}
+		}
+	}
+	if len(squares) == 0 {
+		for sq := range targets {
+			squares = append(squares, sq)
+		}
+	}
+
+	sort.Ints(squares)
+	for _, sq := range squares {
+		if len(targets[sq]) == 0 {
+			fmt.Fprintf(s.out, "The piece on %s has no legal moves.\n", IndexToAlgebraic(sq))
+			continue
+		}
+		writeMoveTargets(s.out, sq, targets[sq])
+	}
+}
+
+// appendTarget adds a target square once; promotions give several moves to the same square
+func appendTarget(targets []int, sq int) []int {
+	for _, t := range targets {
+		if t == sq {
+			return targets
+		}
+	}
+	return append(targets, sq)
+}
+
+// save writes the game played so far to a PGN file
+func (s *playSession) save(filename string) error {
+	g := NewGame(&s.start)
+	g.SetTag("Event", "Casual game")
+	g.SetTag("Date", time.Now().Format("2006.01.02"))
+	if s.human == White {
+		g.SetTag("White", "Human")
+		g.SetTag("Black", engineName)
+	} else {
+		g.SetTag("White", engineName)
+		g.SetTag("Black", "Human")
+	}
+	g.SetTag("Result", s.pos.GameStatus().Result())
+
+	node := g.Root
+	for _, u := range s.pos.undo {
+		node = node.AddMove(u.move)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := g.WritePGN(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SAN returns the legal move m in Standard Algebraic Notation ("Nbd7", "exd6", "O-O-O",
+// "e8=Q+", "Qxf7#"). The from square is given only as far as needed to tell the move
+// apart from other moves of the same piece type to the same square. En passant captures are
+// written without "e.p.", as PGN requires.
+func (p *Position) SAN(m Move) string {
+	from, to := m.From(), m.To()
+	piece := p.pieces[from]
+
+	var sb strings.Builder
+	switch {
+	case m.IsCastle():
+		if squareFile[to] > squareFile[from] {
+			sb.WriteString("O-O")
+		} else {
+			sb.WriteString("O-O-O")
+		}
+	case piece == Pawn:
+		if m.IsCapture() {
+			sb.WriteByte('a' + byte(squareFile[from]))
+			sb.WriteByte('x')
+		}
+		sb.WriteString(IndexToAlgebraic(to))
+		if promotion := m.Promotion(); promotion != Empty {
+			sb.WriteByte('=')
+			sb.WriteByte(pieceLetters[promotion])
+		}
+	default:
+		sb.WriteByte(pieceLetters[piece])
+		sb.WriteString(p.disambiguation(m))
+		if m.IsCapture() {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(IndexToAlgebraic(to))
+	}
+
+	p.MakeMove(m)
+	if p.InCheck(p.side) {
+		if len(p.LegalMoves()) == 0 {
+			sb.WriteByte('#')
+		} else {
+			sb.WriteByte('+')
+		}
+	}
+	p.UnmakeMove()
+	return sb.String()
+}
+
+// disambiguation returns the file, rank or square of the from square of a piece move,
+// whichever is the first to single it out among the moves of the same piece type to the same square
+func (p *Position) disambiguation(m Move) string {
+	from, to := m.From(), m.To()
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range p.LegalMoves() {
+		if other.To() != to || other.From() == from || p.pieces[other.From()] != p.pieces[from] {
+			continue
+		}
+		ambiguous = true
+		sameFile = sameFile || squareFile[other.From()] == squareFile[from]
+		sameRank = sameRank || squareRank[other.From()] == squareRank[from]
+	}
+	square := IndexToAlgebraic(from)
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return square[:1]
+	case !sameRank:
+		return square[1:]
+	default:
+		return square
+	}
+}
+
+// ParseSAN finds the legal move written in Standard Algebraic Notation. It accepts check and
+// mate suffixes, annotations like "!?", an "e.p." suffix, "0-0" for castling, and promotions
+// without "=" ("e8Q"). A missing capture sign is tolerated.
+func (p *Position) ParseSAN(s string) (Move, error) {
+	san := strings.TrimRight(strings.TrimSpace(s), "+#!?")
+	san = strings.TrimRight(strings.TrimSuffix(san, "e.p."), "+# ")
+	if san == "" {
+		return NoMove, fmt.Errorf("invalid move %q", s)
+	}
+
+	// castling
+	switch san {
+	case "O-O", "0-0", "O-O-O", "0-0-0":
+		long := len(san) == 5
+		for _, m := range p.LegalMoves() {
+			if m.IsCastle() && (squareFile[m.To()] < squareFile[m.From()]) == long {
+				return m, nil
+			}
+		}
+		return NoMove, fmt.Errorf("illegal move %q", s)
+	}
+
+	// piece letter, absent for pawn moves
+	piece := Pawn
+	if i := strings.IndexByte(pieceLetters, san[0]); i > 0 {
+		piece = i
+		san = san[1:]
+	}
+
+	// promotion suffix "=Q" or "Q"
+	promotion := Empty
+	if n := len(san); n > 0 {
+		if i := strings.IndexByte(pieceLetters[Knight:King], san[n-1]); i >= 0 {
+			promotion = Knight + i
+			san = strings.TrimSuffix(san[:n-1], "=")
+		}
+	}
+
+	// destination square and what is left of the from square
+	if len(san) < 2 {
+		return NoMove, fmt.Errorf("invalid move %q", s)
+	}
+	to, err := AlgebraicToIndex(san[len(san)-2:])
+	if err != nil {
+		return NoMove, fmt.Errorf("invalid move %q: %v", s, err)
+	}
+	hint := strings.TrimSuffix(san[:len(san)-2], "x")
+	if len(hint) > 2 {
+		return NoMove, fmt.Errorf("invalid move %q", s)
+	}
+	fromFile, fromRank := -1, -1
+	for _, c := range hint {
+		switch {
+		case c >= 'a' && c <= 'h':
+			fromFile = int(c - 'a')
+		case c >= '1' && c <= '8':
+			fromRank = int(c - '1')
+		default:
+			return NoMove, fmt.Errorf("invalid move %q", s)
+		}
+	}
+
+	found := NoMove
+	for _, m := range p.LegalMoves() {
+		from := m.From()
+		if m.To() != to || p.pieces[from] != piece || m.Promotion() != promotion || m.IsCastle() ||
+			(fromFile >= 0 && squareFile[from] != fromFile) || (fromRank >= 0 && squareRank[from] != fromRank) {
+			continue
+		}
+		if found != NoMove {
+			return NoMove, fmt.Errorf("ambiguous move %q: %s and %s", s, p.SAN(found), p.SAN(m))
+		}
+		found = m
+	}
+	if found == NoMove {
+		if piece == Pawn && promotion == Empty && (squareRank[to] == 0 || squareRank[to] == 7) {
+			return NoMove, fmt.Errorf("illegal move %q: missing promotion piece", s)
+		}
+		return NoMove, fmt.Errorf("illegal move %q", s)
+	}
+	return found, nil
+}
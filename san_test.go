@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		fen, move, want string
+	}{
+		{StartFEN, "e2e4", "e4"},
+		{StartFEN, "g1f3", "Nf3"},
+		{"k7/8/8/4p3/8/5N2/8/K7 w - - 0 1", "f3e5", "Nxe5"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4d5", "exd5"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", "exf6"},
+		{"7k/8/8/8/8/8/8/R4R1K w - - 0 1", "a1c1", "Rac1"},
+		{"7k/8/8/R7/8/8/8/R5K1 w - - 0 1", "a1a3", "R1a3"},
+		{"8/7k/8/8/8/Q7/8/Q1Q4K w - - 0 1", "a1b2", "Qa1b2"},
+		{"k7/4P3/8/8/8/8/8/K7 w - - 0 1", "e7e8q", "e8=Q+"},
+		{"k7/4P3/8/8/8/8/8/K7 w - - 0 1", "e7e8n", "e8=N"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1c1", "O-O-O"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4", "Qh4#"},
+	}
+	for _, tt := range tests {
+		p, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := p.ParseMove(tt.move)
+		if err != nil {
+			t.Errorf("%s: %v", tt.fen, err)
+			continue
+		}
+		if got := p.SAN(m); got != tt.want {
+			t.Errorf("%s: SAN(%s) = %q, want %q", tt.fen, tt.move, got, tt.want)
+		}
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	tests := []struct {
+		fen, san, want string
+	}{
+		{StartFEN, "e4", "e2e4"},
+		{StartFEN, "Nf3", "g1f3"},
+		{StartFEN, "Nf3!?", "g1f3"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "exd5", "e4d5"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "ed5", "e4d5"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6", "e5f6"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6 e.p.", "e5f6"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6e.p.+", "e5f6"},
+		{"7k/8/8/8/8/8/8/R4R1K w - - 0 1", "Rac1", "a1c1"},
+		{"7k/8/8/R7/8/8/8/R5K1 w - - 0 1", "R1a3", "a1a3"},
+		{"8/7k/8/8/8/Q7/8/Q1Q4K w - - 0 1", "Qa1b2", "a1b2"},
+		{"k7/4P3/8/8/8/8/8/K7 w - - 0 1", "e8=Q+", "e7e8q"},
+		{"k7/4P3/8/8/8/8/8/K7 w - - 0 1", "e8Q", "e7e8q"},
+		{"k7/4P3/8/8/8/8/8/K7 w - - 0 1", "e8=N", "e7e8n"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", "e1g1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0-0", "e1c1"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "Qh4#", "d8h4"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "Qh4", "d8h4"},
+	}
+	for _, tt := range tests {
+		p, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := p.ParseSAN(tt.san)
+		if err != nil {
+			t.Errorf("%s: ParseSAN(%q): %v", tt.fen, tt.san, err)
+			continue
+		}
+		if got := m.String(); got != tt.want {
+			t.Errorf("%s: ParseSAN(%q) = %s, want %s", tt.fen, tt.san, got, tt.want)
+		}
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	tests := []struct {
+		fen, san, err string
+	}{
+		{StartFEN, "", "invalid move"},
+		{StartFEN, "+", "invalid move"},
+		{StartFEN, "e9", "invalid move"},
+		{StartFEN, "Zf3", "invalid move"},
+		{StartFEN, "Nf4", "illegal move"},
+		{StartFEN, "O-O", "illegal move"},
+		{"7k/8/8/8/8/8/8/R4R1K w - - 0 1", "Rc1", "ambiguous move \"Rc1\": Rac1 and Rfc1"},
+		{"k7/4P3/8/8/8/8/8/K7 w - - 0 1", "e8", "missing promotion piece"},
+	}
+	for _, tt := range tests {
+		p, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.ParseSAN(tt.san)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: ParseSAN(%q) error = %v, want %q", tt.fen, tt.san, err, tt.err)
+		}
+	}
+}
+
+// TestSANRoundTrip formats and parses back every legal move of the perft positions
+func TestSANRoundTrip(t *testing.T) {
+	for _, tt := range perftSuite {
+		p, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range p.LegalMoves() {
+			san := p.SAN(m)
+			got, err := p.ParseSAN(san)
+			if err != nil || got != m {
+				t.Errorf("%s: ParseSAN(%q) = %s, %v, want %s", tt.fen, san, got, err, m)
+			}
+		}
+	}
+}
+
+func TestAlgebraicToIndex(t *testing.T) {
+	tests := []struct {
+		s  string
+		sq int
+	}{
+		{"a1", 0}, {"h1", 7}, {"e4", 28}, {"a8", 56}, {"h8", 63},
+	}
+	for _, tt := range tests {
+		if sq, err := AlgebraicToIndex(tt.s); err != nil || sq != tt.sq {
+			t.Errorf("AlgebraicToIndex(%q) = %d, %v, want %d", tt.s, sq, err, tt.sq)
+		}
+	}
+	for _, s := range []string{"", "a", "e44", "i1", "a0", "a9", "E4", "4e"} {
+		if _, err := AlgebraicToIndex(s); err == nil {
+			t.Errorf("AlgebraicToIndex(%q) accepted an invalid square", s)
+		}
+	}
+	for sq := 0; sq < 64; sq++ {
+		if got, err := AlgebraicToIndex(IndexToAlgebraic(sq)); err != nil || got != sq {
+			t.Errorf("AlgebraicToIndex(IndexToAlgebraic(%d)) = %d, %v", sq, got, err)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// search score bounds
+const (
+	maxPly    = 128                // deepest ply the search can reach
+	infinity  = 32000              // larger than any score
+	mateScore = 31000              // score for giving mate at the root; mate at ply n scores mateScore - n
+	mateBound = mateScore - maxPly // scores beyond this are mate scores
+)
+
+// SearchLimits tells the search when to stop. Zero values mean no limit.
+type SearchLimits struct {
+	Depth    int           // maximum depth in plies
+	Nodes    uint64        // maximum number of nodes
+	MoveTime time.Duration // maximum time for the whole search
+
+	// clock of the side to move; the time manager splits it over the moves to go
+	Time         time.Duration // remaining time
+	Increment    time.Duration // time added after every move
+	MovesToGo    int           // moves until the next time control, 0 for sudden death
+	MoveOverhead time.Duration // reserved per move for communication delays
+}
+
+// SearchResult is the outcome of a completed iteration of the search.
+type SearchResult struct {
+	BestMove Move
+	Score    int    // centipawns from the side to move's point of view, or a mate score
+	PV       []Move // principal variation, starting with BestMove
+	Depth    int    // depth of the last completed iteration
+	Nodes    uint64
+	Time     time.Duration
+}
+
+// MateIn returns the number of moves until mate and true if the score is a mate score.
+// N is negative when the side to move gets mated.
+func (r SearchResult) MateIn() (int, bool) {
+	return mateDistance(r.Score)
+}
+
+// ScoreString formats the score as "+0.35" or "mate in 3" / "mated in 2".
+func (r SearchResult) ScoreString() string {
+	if n, ok := r.MateIn(); ok {
+		if n > 0 {
+			return fmt.Sprintf("mate in %d", n)
+		}
+		return fmt.Sprintf("mated in %d", -n)
+	}
+	return fmt.Sprintf("%+.2f", float64(r.Score)/100)
+}
+
+// mateDistance converts a mate score into moves (not plies) until mate
+func mateDistance(score int) (int, bool) {
+	if score > mateBound {
+		return (mateScore - score + 1) / 2, true
+	}
+	if score < -mateBound {
+		return -(mateScore + score) / 2, true
+	}
+	return 0, false
+}
+
+// searcher holds the state of one running search
+type searcher struct {
+	pos     Position
+	ctx     context.Context
+	limits  SearchLimits
+	nodes   uint64
+	stopped bool
+
+	moveBuf [maxPly][256]Move        // move list per ply, reused to avoid allocations
+	pv      [maxPly + 1][maxPly]Move // triangular principal variation table
+	pvLen   [maxPly + 1]int
+	pickers [maxPly]movePicker
+
+	killers [maxPly][2]Move // quiet moves that recently caused a beta cutoff, per ply
+	history [2][64][64]int  // butterfly history of quiet moves by side, from and to square
+
+	tt *TranspositionTable
+}
+
+// Search runs an iterative-deepening alpha-beta search on pos and returns the result
+// of the deepest completed iteration. It stops when a limit is reached or ctx is cancelled.
+// report, if not nil, is called after every completed iteration.
+func Search(ctx context.Context, pos *Position, limits SearchLimits, report func(SearchResult)) SearchResult {
+	start := time.Now()
+	tm := newTimeManager(limits, start)
+	if tm.hard > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, start.Add(tm.hard))
+		defer cancel()
+	}
+
+	s := &searcher{pos: pos.Clone(), ctx: ctx, limits: limits, tt: transpositionTable}
+	s.tt.NewSearch()
+
+	maxDepth := maxPly - 1
+	if limits.Depth > 0 && limits.Depth < maxDepth {
+		maxDepth = limits.Depth
+	}
+
+	var result SearchResult
+	moves := s.pos.LegalMoves()
+	if len(moves) > 0 {
+		// always have a move to play, even if the first iteration is interrupted
+		result.BestMove = moves[0]
+		result.PV = []Move{moves[0]}
+	}
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		score := s.negamax(depth, 0, -infinity, infinity)
+		if s.stopped {
+			break
+		}
+
+		result = SearchResult{
+			Score: score,
+			PV:    append([]Move(nil), s.pv[0][:s.pvLen[0]]...),
+			Depth: depth,
+			Nodes: s.nodes,
+			Time:  time.Since(start),
+		}
+		if len(result.PV) > 0 {
+			result.BestMove = result.PV[0]
+		}
+		if report != nil {
+			report(result)
+		}
+
+		// no need to search deeper once a forced mate has been fully seen
+		if n, ok := mateDistance(score); ok && depth >= 2*abs(n) {
+			break
+		}
+		if tm.stopAfterIteration(result, len(moves)) {
+			break
+		}
+	}
+
+	result.Nodes = s.nodes
+	result.Time = time.Since(start)
+	return result
+}
+
+// checkStop sets stopped when the context is cancelled or the node budget is used up
+func (s *searcher) checkStop() {
+	if s.limits.Nodes > 0 && s.nodes >= s.limits.Nodes {
+		s.stopped = true
+	}
+	if s.nodes&1023 == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+}
+
+// negamax searches the position to the given depth with alpha-beta pruning and
+// returns the score from the side to move's point of view.
+func (s *searcher) negamax(depth, ply, alpha, beta int) int {
+	s.pvLen[ply] = 0
+	s.nodes++
+	s.checkStop()
+	if s.stopped {
+		return 0
+	}
+
+	p := &s.pos
+
+	// repetitions inside the search and dead positions are draws, see GameStatus
+	if ply > 0 && (p.repeated() || p.InsufficientMaterial()) {
+		return 0
+	}
+
+	// a stored result that searched at least as deep can decide this node if it fails high
+	// or low, except at the root where a move has to be returned. A score inside the window
+	// would become part of the principal variation, which the table cannot give, so the
+	// node is searched to keep the PV whole.
+	ttMove := NoMove
+	if entry, ok := s.tt.Probe(p.hash); ok {
+		ttMove = entry.move
+		if ply > 0 && entry.depth >= depth {
+			score := scoreFromTT(entry.score, ply)
+			switch {
+			case entry.bound != boundUpper && score >= beta,
+				entry.bound != boundLower && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	moves := p.GenerateLegalMoves(s.moveBuf[ply][:0])
+	if len(moves) == 0 {
+		if p.InCheck(p.side) {
+			return -mateScore + ply
+		}
+		return 0 // stalemate
+	}
+	// checkmate on the hundredth half-move still counts, otherwise the fifty-move rule applies
+	if ply > 0 && p.halfmoveClock >= 100 {
+		return 0
+	}
+	if depth <= 0 || ply >= maxPly-1 {
+		return s.quiesce(ply, alpha, beta)
+	}
+
+	origAlpha := alpha
+	bestMove := NoMove
+	var quiets [256]Move // quiet moves searched without a cutoff, they lose history on a cutoff
+	numQuiets := 0
+	mp := s.newMovePicker(moves, ttMove, ply)
+	for {
+		m, _, ok := mp.nextMove()
+		if !ok {
+			break
+		}
+		quiet := !m.IsCapture() && m.Promotion() == Empty
+
+		p.MakeMove(m)
+		score := -s.negamax(depth-1, ply+1, -beta, -alpha)
+		p.UnmakeMove()
+		if s.stopped {
+			return 0
+		}
+
+		if score > alpha {
+			alpha = score
+			bestMove = m
+			s.pv[ply][0] = m
+			copy(s.pv[ply][1:], s.pv[ply+1][:s.pvLen[ply+1]])
+			s.pvLen[ply] = s.pvLen[ply+1] + 1
+			if score >= beta {
+				if quiet {
+					s.updateQuietStats(m, quiets[:numQuiets], depth, ply)
+				}
+				break
+			}
+		}
+		if quiet {
+			quiets[numQuiets] = m
+			numQuiets++
+		}
+	}
+
+	bound := boundUpper
+	if alpha >= beta {
+		bound = boundLower
+	} else if alpha > origAlpha {
+		bound = boundExact
+	}
+	s.tt.Store(p.hash, bestMove, scoreToTT(alpha, ply), depth, bound)
+	return alpha
+}
+
+// deltaMargin is added to the value of a capture before delta pruning gives up on it,
+// to allow for positional gains the capture may bring
+const deltaMargin = 200
+
+// deltaValue is the most the capture or promotion of a piece can change the evaluation by:
+// the larger of its opening and endgame material value
+func deltaValue(piece int) int {
+	return maxInt(materialOpening[piece], materialEndgame[piece])
+}
+
+// quiesce resolves captures and promotions at the leaves so the static evaluation is only
+// taken in quiet positions. The side to move may stand pat on the evaluation unless in check.
+func (s *searcher) quiesce(ply, alpha, beta int) int {
+	s.pvLen[ply] = 0
+	s.nodes++
+	s.checkStop()
+	if s.stopped {
+		return 0
+	}
+
+	p := &s.pos
+	if ply >= maxPly-1 {
+		return p.Evaluate()
+	}
+
+	// in check every evasion has to be searched, there is no standing pat
+	inCheck := p.InCheck(p.side)
+	var moves []Move
+	standPat := -infinity
+	if inCheck {
+		moves = p.GenerateLegalMoves(s.moveBuf[ply][:0])
+		if len(moves) == 0 {
+			return -mateScore + ply
+		}
+	} else {
+		standPat = p.Evaluate()
+		if standPat >= beta {
+			return standPat
+		}
+		// delta pruning: not even winning a queen would lift the score to alpha, unless a pawn
+		// about to promote can add another queen
+		seventh := rankMask(6)
+		if p.side == Black {
+			seventh = rankMask(1)
+		}
+		if standPat+deltaValue(Queen)+deltaMargin < alpha && p.pieceBB[p.side][Pawn]&seventh == 0 {
+			return alpha
+		}
+		if standPat > alpha {
+			alpha = standPat
+		}
+		moves = p.GenerateLegalCaptures(s.moveBuf[ply][:0])
+	}
+
+	mp := s.newMovePicker(moves, NoMove, ply)
+	for {
+		m, order, ok := mp.nextMove()
+		if !ok {
+			break
+		}
+		if !inCheck {
+			// delta pruning for this capture, then drop captures that lose material
+			gain := deltaValue(p.pieces[m.To()])
+			if m.IsEnPassant() {
+				gain = deltaValue(Pawn)
+			}
+			if promotion := m.Promotion(); promotion != Empty {
+				gain += deltaValue(promotion) - deltaValue(Pawn)
+			}
+			if standPat+gain+deltaMargin < alpha {
+				continue
+			}
+			if order < 0 {
+				continue
+			}
+		}
+
+		p.MakeMove(m)
+		score := -s.quiesce(ply+1, -beta, -alpha)
+		p.UnmakeMove()
+		if s.stopped {
+			return 0
+		}
+
+		if score > alpha {
+			alpha = score
+			s.pv[ply][0] = m
+			copy(s.pv[ply][1:], s.pv[ply+1][:s.pvLen[ply+1]])
+			s.pvLen[ply] = s.pvLen[ply+1] + 1
+			if score >= beta {
+				break
+			}
+		}
+	}
+	return alpha
+}
+
+// abs returns the absolute value of x
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// minInt returns the smaller of a and b
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the larger of a and b
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearchFindsMate(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		depth  int
+		best   string // empty when several moves mate equally fast
+		mateIn int
+	}{
+		{"back rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 4, "a1a8", 1},
+		{"rook ladder", "7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", 5, "", 2},
+		{"smothered mate", "r5rk/6pp/7N/8/8/8/8/6K1 w - - 0 1", 4, "h6f7", 1},
+	}
+	for _, tt := range tests {
+		pos, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		transpositionTable.Clear()
+		r := Search(context.Background(), &pos, SearchLimits{Depth: tt.depth}, nil)
+		if n, ok := r.MateIn(); !ok || n != tt.mateIn {
+			t.Errorf("%s: score %s, want mate in %d", tt.name, r.ScoreString(), tt.mateIn)
+		}
+		if tt.best != "" && r.BestMove.String() != tt.best {
+			t.Errorf("%s: best move %s, want %s", tt.name, r.BestMove, tt.best)
+		}
+	}
+}
+
+func TestSearchLimits(t *testing.T) {
+	pos := NewPosition()
+	r := Search(context.Background(), &pos, SearchLimits{Nodes: 5000}, nil)
+	if r.BestMove == NoMove || r.Nodes > 5000+maxPly {
+		t.Errorf("node limit: best move %s after %d nodes", r.BestMove, r.Nodes)
+	}
+
+	// a search stopped before it starts still returns a legal move
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = Search(ctx, &pos, SearchLimits{}, nil)
+	if _, err := pos.ParseMove(r.BestMove.String()); err != nil {
+		t.Errorf("cancelled search returned %s: %v", r.BestMove, err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+// SEE returns the static exchange evaluation of move m: the material the side to move
+// wins or loses on the target square when both sides keep recapturing with their least
+// valuable piece and may stop whenever continuing would lose material. Values come from
+// pieceValues. Non-captures return the result of the exchange the move invites, castling 0.
+func (p *Position) SEE(m Move) int {
+	if m.IsCastle() {
+		return 0
+	}
+	from, to := m.From(), m.To()
+	occupied := p.occupied() &^ squareBB(from)
+
+	var gain [32]int
+	if m.IsEnPassant() {
+		gain[0] = pieceValues[Pawn]
+		captured := to - 8
+		if p.side == Black {
+			captured = to + 8
+		}
+		occupied &^= squareBB(captured)
+	} else {
+		gain[0] = pieceValues[p.pieces[to]]
+	}
+
+	// the piece standing on the target square, which the opponent may capture next
+	victim := p.pieces[from]
+	if promotion := m.Promotion(); promotion != Empty {
+		gain[0] += pieceValues[promotion] - pieceValues[Pawn]
+		victim = promotion
+	}
+
+	bishops := p.pieceBB[White][Bishop] | p.pieceBB[Black][Bishop] | p.pieceBB[White][Queen] | p.pieceBB[Black][Queen]
+	rooks := p.pieceBB[White][Rook] | p.pieceBB[Black][Rook] | p.pieceBB[White][Queen] | p.pieceBB[Black][Queen]
+	attackers := p.attackersTo(to, occupied) & occupied
+
+	side := p.side
+	d := 0
+	for {
+		side ^= 1
+		sideAttackers := attackers & p.colorBB[side]
+		if sideAttackers == 0 {
+			break
+		}
+
+		// least valuable attacker
+		piece := Pawn
+		for ; piece <= King; piece++ {
+			if sideAttackers&p.pieceBB[side][piece] != 0 {
+				break
+			}
+		}
+		sq := lsb(sideAttackers & p.pieceBB[side][piece])
+
+		// a king may only recapture when the other side has no attacker left
+		if piece == King && attackers&p.colorBB[side^1]&^squareBB(sq) != 0 {
+			break
+		}
+
+		d++
+		gain[d] = pieceValues[victim] - gain[d-1]
+		if maxInt(-gain[d-1], gain[d]) < 0 {
+			// neither side can improve by going on
+			break
+		}
+
+		// remove the attacker and add sliders that attack through its square
+		occupied &^= squareBB(sq)
+		attackers |= bishopAttacks(to, occupied)&bishops | rookAttacks(to, occupied)&rooks
+		attackers &= occupied
+		victim = piece
+	}
+
+	for ; d > 0; d-- {
+		gain[d-1] = -maxInt(-gain[d-1], gain[d])
+	}
+	return gain[0]
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestSEE(t *testing.T) {
+	P, N, R, Q := pieceValues[Pawn], pieceValues[Knight], pieceValues[Rook], pieceValues[Queen]
+	tests := []struct {
+		name, fen, move string
+		want            int
+	}{
+		{"undefended knight", "4k3/8/8/3n4/4P3/8/8/4K3 w - - 0 1", "e4d5", N},
+		{"pawn takes defended knight", "4k3/8/4p3/3n4/4P3/8/8/4K3 w - - 0 1", "e4d5", N - P},
+		{"queen takes pawn defended by a pawn", "4k3/8/4p3/3p4/8/8/8/3QK3 w - - 0 1", "d1d5", P - Q},
+		{"rook takes rook-defended pawn", "3rk3/8/8/3p4/8/8/3R4/4K3 w - - 0 1", "d2d5", P - R},
+		{"x-ray through a rook battery", "3rk3/8/8/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", P},
+		{"x-ray through a queen behind a bishop", "4k3/8/5n2/3p4/8/5B2/6Q1/4K3 w - - 0 1", "f3d5", P},
+		{"king cannot recapture a defended piece", "8/8/4k3/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", P},
+		{"king recaptures an undefended piece", "8/8/4k3/3p4/8/8/3R4/4K3 w - - 0 1", "d2d5", P - R},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", P},
+		{"en passant into a recapture", "4k3/2p5/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 0},
+		{"promotion capture", "r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7a8q", R + Q - P},
+		{"defended promotion capture", "r3k3/1P6/1n6/8/8/8/8/4K3 w - - 0 1", "b7a8q", R - P},
+		{"quiet move to a square attacked by a pawn", "4k3/8/4p3/8/8/4N3/8/4K3 w - - 0 1", "e3d5", -N},
+		{"quiet move to a safe square", StartFEN, "g1f3", 0},
+		{"castling", "4k3/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", 0},
+	}
+	for _, tt := range tests {
+		p, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		m, err := p.ParseMove(tt.move)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := p.SEE(m); got != tt.want {
+			t.Errorf("%s: SEE(%s) = %d, want %d", tt.name, tt.move, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import "time"
+
+// default and maximum time reserved per move for the delay between the engine sending its
+// move and the GUI stopping the clock
+const (
+	defaultMoveOverhead = 30 * time.Millisecond
+	maxMoveOverhead     = 5 * time.Second
+)
+
+// defaultMovesToGo is the number of moves the remaining time is split over in sudden death games
+const defaultMovesToGo = 30
+
+// timeManager decides how long the search may think about one move. The hard limit ends the
+// search even in the middle of an iteration. The soft limit is checked after every iteration and
+// is stretched while the best move keeps changing or the score drops.
+type timeManager struct {
+	start      time.Time
+	soft, hard time.Duration // zero without a time limit
+
+	bestMove    Move
+	score       int
+	instability float64 // best move changes, halved every iteration
+	stable      int     // iterations in a row with the same best move
+}
+
+// newTimeManager works out the soft and hard limit for the search from limits
+func newTimeManager(limits SearchLimits, start time.Time) *timeManager {
+	tm := &timeManager{start: start}
+	overhead := limits.MoveOverhead
+
+	switch {
+	case limits.MoveTime > 0:
+		tm.hard = limits.MoveTime - overhead
+		tm.soft = tm.hard
+	case limits.Time > 0:
+		movesToGo := limits.MovesToGo
+		if movesToGo <= 0 {
+			movesToGo = defaultMovesToGo
+		}
+		available := limits.Time - overhead
+
+		tm.soft = available/time.Duration(movesToGo) + limits.Increment*3/4
+		// keep enough for the moves after this one, unless it is the last before the time control
+		tm.hard = available / 2
+		if movesToGo == 1 {
+			tm.hard = available * 4 / 5
+		}
+		if tm.hard > 5*tm.soft {
+			tm.hard = 5 * tm.soft
+		}
+		if tm.soft > tm.hard {
+			tm.soft = tm.hard
+		}
+	default:
+		return tm
+	}
+
+	if tm.hard < time.Millisecond {
+		tm.hard = time.Millisecond
+	}
+	if tm.soft < time.Millisecond {
+		tm.soft = time.Millisecond
+	}
+	return tm
+}
+
+// stopAfterIteration records a completed iteration and reports whether the search should
+// stop instead of starting the next one. legalMoves is the number of moves at the root.
+func (tm *timeManager) stopAfterIteration(r SearchResult, legalMoves int) bool {
+	if tm.soft == 0 {
+		return false
+	}
+	// a forced move needs no thought
+	if legalMoves == 1 {
+		return true
+	}
+
+	tm.instability /= 2
+	if r.BestMove == tm.bestMove {
+		tm.stable++
+	} else {
+		if r.Depth > 1 {
+			tm.instability++
+		}
+		tm.stable = 0
+	}
+
+	scale := 1 + tm.instability/2
+	if drop := tm.score - r.Score; r.Depth > 1 && drop > 20 {
+		if drop > 200 {
+			drop = 200
+		}
+		scale *= 1 + float64(drop)/400
+	}
+	// the same best move for many iterations is most likely the right one
+	if tm.stable >= 6 {
+		scale *= 0.7
+	}
+	tm.bestMove, tm.score = r.BestMove, r.Score
+
+	limit := time.Duration(float64(tm.soft) * scale)
+	if limit > tm.hard {
+		limit = tm.hard
+	}
+	return time.Since(tm.start) >= limit
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTimeManager(t *testing.T) {
+	const ms = time.Millisecond
+	tests := []struct {
+		name       string
+		limits     SearchLimits
+		soft, hard time.Duration
+	}{
+		{"no limit", SearchLimits{Depth: 5}, 0, 0},
+		{"move time", SearchLimits{MoveTime: 1000 * ms, MoveOverhead: 30 * ms}, 970 * ms, 970 * ms},
+		{"move time below overhead", SearchLimits{MoveTime: 20 * ms, MoveOverhead: 50 * ms}, ms, ms},
+		// sudden death splits the time over defaultMovesToGo moves
+		{"sudden death", SearchLimits{Time: 60030 * ms, MoveOverhead: 30 * ms}, 2000 * ms, 10000 * ms},
+		{"increment", SearchLimits{Time: 60000 * ms, Increment: 1000 * ms}, 2750 * ms, 13750 * ms},
+		{"moves to go", SearchLimits{Time: 10100 * ms, MovesToGo: 10, MoveOverhead: 100 * ms}, 1000 * ms, 5000 * ms},
+		{"last move before the time control", SearchLimits{Time: 10000 * ms, MovesToGo: 1}, 8000 * ms, 8000 * ms},
+		{"hard limit halves the clock", SearchLimits{Time: 2000 * ms, MovesToGo: 2, Increment: 1000 * ms},
+			1000 * ms, 1000 * ms},
+		{"overhead uses up the clock", SearchLimits{Time: 40 * ms, MoveOverhead: 100 * ms}, ms, ms},
+	}
+	for _, tt := range tests {
+		tm := newTimeManager(tt.limits, time.Now())
+		if tm.soft != tt.soft || tm.hard != tt.hard {
+			t.Errorf("%s: soft %v, hard %v, want %v, %v", tt.name, tm.soft, tm.hard, tt.soft, tt.hard)
+		}
+	}
+}
+
+func TestStopAfterIteration(t *testing.T) {
+	e2e4 := newMove(int(E2), int(E4), Empty, flagDoublePush)
+	d2d4 := newMove(int(D2), int(D4), Empty, flagDoublePush)
+
+	// the soft limit has passed, but a changing best move and a falling score stretch it
+	limits := SearchLimits{Time: 10 * time.Second, MovesToGo: 10} // soft limit 1s, hard limit 5s
+	tm := newTimeManager(limits, time.Now().Add(-1200*time.Millisecond))
+	if !tm.stopAfterIteration(SearchResult{BestMove: e2e4, Depth: 1, Score: 50}, 20) {
+		t.Error("did not stop after the soft limit")
+	}
+	if tm.stopAfterIteration(SearchResult{BestMove: d2d4, Depth: 2, Score: -50}, 20) {
+		t.Error("stopped although the best move changed and the score dropped")
+	}
+
+	tm = newTimeManager(SearchLimits{MoveTime: time.Second}, time.Now())
+	if !tm.stopAfterIteration(SearchResult{BestMove: e2e4, Depth: 1}, 1) {
+		t.Error("kept thinking about the only legal move")
+	}
+	tm = newTimeManager(SearchLimits{Depth: 3}, time.Now().Add(-time.Hour))
+	if tm.stopAfterIteration(SearchResult{BestMove: e2e4, Depth: 1}, 1) {
+		t.Error("stopped a search without time limit")
+	}
+}
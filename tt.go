@@ -0,0 +1,181 @@
+package main
+
+import "sync/atomic"
+
+// bound types of a transposition table score
+const (
+	boundNone  = iota
+	boundUpper // the score is at most this value (no move raised alpha)
+	boundLower // the score is at least this value (a move failed high)
+	boundExact // the score is exact (a principal variation node)
+)
+
+// default and maximum size of the transposition table in megabytes
+const (
+	defaultHashMB = 16
+	maxHashMB     = 4096
+)
+
+// ttBucketSize entries share one bucket; a bucket of 4 entries fills one 64 byte cache line
+const ttBucketSize = 4
+
+// ttEntry is stored as two words. key holds the position hash XOR data, so an entry torn by a
+// concurrent write from another thread fails verification instead of returning wrong data.
+//
+// data layout: bits 0-19 move, 20-35 score (int16), 36-43 depth, 44-45 bound, 46-53 age
+type ttEntry struct {
+	key  uint64
+	data uint64
+}
+
+// ttData is the decoded content of a transposition table entry
+type ttData struct {
+	move  Move
+	score int
+	depth int
+	bound int
+	age   uint8
+}
+
+// TranspositionTable caches search results by position hash. It is safe for concurrent
+// use by several searches without locks.
+type TranspositionTable struct {
+	buckets [][ttBucketSize]ttEntry
+	mask    uint64
+	age     uint32 // incremented for every new search, older entries are replaced first
+}
+
+// transpositionTable is the table shared by all searches
+var transpositionTable = NewTranspositionTable(defaultHashMB)
+
+// NewTranspositionTable allocates a table of about mb megabytes.
+func NewTranspositionTable(mb int) *TranspositionTable {
+	t := &TranspositionTable{}
+	t.Resize(mb)
+	return t
+}
+
+// Resize reallocates the table with about mb megabytes (rounded down to a power of two
+// number of buckets) and clears it. It must not be called while a search runs.
+func (t *TranspositionTable) Resize(mb int) {
+	if mb < 1 {
+		mb = 1
+	}
+	if mb > maxHashMB {
+		mb = maxHashMB
+	}
+	n := uint64(1)
+	for n*2*ttBucketSize*16 <= uint64(mb)<<20 {
+		n *= 2
+	}
+	t.buckets = make([][ttBucketSize]ttEntry, n)
+	t.mask = n - 1
+}
+
+// Clear empties the table. It must not be called while a search runs.
+func (t *TranspositionTable) Clear() {
+	for i := range t.buckets {
+		t.buckets[i] = [ttBucketSize]ttEntry{}
+	}
+	atomic.StoreUint32(&t.age, 0)
+}
+
+// NewSearch ages the table so entries from earlier searches are replaced first.
+func (t *TranspositionTable) NewSearch() {
+	atomic.AddUint32(&t.age, 1)
+}
+
+// packTTData packs an entry's fields into one word
+func packTTData(d ttData) uint64 {
+	return uint64(d.move)&0xfffff |
+		uint64(uint16(int16(d.score)))<<20 |
+		uint64(uint8(d.depth))<<36 |
+		uint64(d.bound&3)<<44 |
+		uint64(d.age)<<46
+}
+
+// unpackTTData is the reverse of packTTData
+func unpackTTData(data uint64) ttData {
+	return ttData{
+		move:  Move(data & 0xfffff),
+		score: int(int16(uint16(data >> 20))),
+		depth: int(uint8(data >> 36)),
+		bound: int(data>>44) & 3,
+		age:   uint8(data >> 46),
+	}
+}
+
+// Probe looks up the position hash and returns the stored data if there is an entry for it.
+func (t *TranspositionTable) Probe(hash uint64) (ttData, bool) {
+	bucket := &t.buckets[hash&t.mask]
+	for i := range bucket {
+		data := atomic.LoadUint64(&bucket[i].data)
+		key := atomic.LoadUint64(&bucket[i].key)
+		if key^data == hash && data != 0 {
+			return unpackTTData(data), true
+		}
+	}
+	return ttData{}, false
+}
+
+// Store saves a search result for the position hash. Within the bucket it overwrites the entry
+// of the same position, otherwise the one with the least depth, preferring entries of older searches.
+func (t *TranspositionTable) Store(hash uint64, move Move, score, depth, bound int) {
+	age := uint8(atomic.LoadUint32(&t.age))
+	bucket := &t.buckets[hash&t.mask]
+
+	replace := 0
+	worst := 1 << 30
+	for i := range bucket {
+		data := atomic.LoadUint64(&bucket[i].data)
+		key := atomic.LoadUint64(&bucket[i].key)
+		if key^data == hash {
+			old := unpackTTData(data)
+			// keep the known best move and a deeper result of the current search
+			if move == NoMove {
+				move = old.move
+			}
+			if bound != boundExact && old.age == age && old.depth > depth {
+				return
+			}
+			replace = i
+			break
+		}
+		old := unpackTTData(data)
+		value := old.depth - 8*int(age-old.age)
+		if data == 0 {
+			value = -1 << 20
+		}
+		if value < worst {
+			worst = value
+			replace = i
+		}
+	}
+
+	data := packTTData(ttData{move: move, score: score, depth: depth, bound: bound, age: age})
+	atomic.StoreUint64(&bucket[replace].data, data)
+	atomic.StoreUint64(&bucket[replace].key, hash^data)
+}
+
+// scoreToTT makes mate scores relative to the current node before storing them,
+// so they stay correct when the position is reached at a different ply
+func scoreToTT(score, ply int) int {
+	if score > mateBound {
+		return score + ply
+	}
+	if score < -mateBound {
+		return score - ply
+	}
+	return score
+}
+
+// scoreFromTT converts a stored mate score back to a score relative to the root
+func scoreFromTT(score, ply int) int {
+	if score > mateBound {
+		return score - ply
+	}
+	if score < -mateBound {
+		return score + ply
+	}
+	return score
+}
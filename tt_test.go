@@ -0,0 +1,134 @@
+package main
+
+import "testing"
+
+func TestScoreTT(t *testing.T) {
+	tests := []struct {
+		name                 string
+		score, storePly, ply int
+		want                 int
+	}{
+		{"plain score", 150, 3, 7, 150},
+		{"negative plain score", -420, 9, 2, -420},
+		// mate 4 plies below the node, found at ply 3 and reached again at ply 7
+		{"mate deeper", mateScore - 7, 3, 7, mateScore - 11},
+		{"mate shallower", mateScore - 9, 5, 1, mateScore - 5},
+		{"mated deeper", -mateScore + 6, 2, 8, -mateScore + 12},
+		{"mated shallower", -mateScore + 10, 10, 4, -mateScore + 4},
+	}
+	for _, tt := range tests {
+		if got := scoreFromTT(scoreToTT(tt.score, tt.storePly), tt.ply); got != tt.want {
+			t.Errorf("%s: score %d stored at ply %d and probed at ply %d = %d, want %d",
+				tt.name, tt.score, tt.storePly, tt.ply, got, tt.want)
+		}
+
+		// the same through the table
+		table := NewTranspositionTable(1)
+		table.Store(0x1234567890abcdef, NoMove, scoreToTT(tt.score, tt.storePly), 4, boundExact)
+		entry, ok := table.Probe(0x1234567890abcdef)
+		if !ok {
+			t.Errorf("%s: stored entry not found", tt.name)
+			continue
+		}
+		if got := scoreFromTT(entry.score, tt.ply); got != tt.want {
+			t.Errorf("%s: score from the table = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTTPacking(t *testing.T) {
+	tests := []ttData{
+		{newMove(int(E2), int(E4), Empty, flagDoublePush), 35, 1, boundExact, 0},
+		{newMove(int(B7), int(A8), Queen, flagCapture), -mateScore + 2, maxPly - 1, boundLower, 255},
+		{newMove(int(E1), int(G1), Empty, flagCastle), mateScore - 1, 0, boundUpper, 17},
+		{NoMove, -1, 200, boundNone, 1},
+	}
+	for _, want := range tests {
+		if got := unpackTTData(packTTData(want)); got != want {
+			t.Errorf("packed %+v, unpacked %+v", want, got)
+		}
+	}
+
+	table := NewTranspositionTable(1)
+	for i := 0; i < 3; i++ {
+		table.NewSearch()
+	}
+	move := newMove(int(D5), int(E6), Empty, flagCapture|flagEnPassant)
+	table.Store(42, move, -517, 9, boundUpper)
+	want := ttData{move, -517, 9, boundUpper, 3}
+	if got, ok := table.Probe(42); !ok || got != want {
+		t.Errorf("Probe = %+v, %v, want %+v", got, ok, want)
+	}
+}
+
+func TestTTVerification(t *testing.T) {
+	table := NewTranspositionTable(1)
+	hash := uint64(0x9e3779b97f4a7c15)
+	table.Store(hash, newMove(int(G1), int(F3), Empty, 0), 20, 6, boundExact)
+
+	// a different position in the same bucket
+	collision := hash ^ 1<<60
+	if collision&table.mask != hash&table.mask {
+		t.Fatal("test hashes do not share a bucket")
+	}
+	if entry, ok := table.Probe(collision); ok {
+		t.Errorf("colliding hash probed %+v", entry)
+	}
+	if _, ok := table.Probe(0); ok {
+		t.Error("empty entry matched the zero hash")
+	}
+
+	// an entry torn by a concurrent write no longer matches either key
+	bucket := &table.buckets[hash&table.mask]
+	bucket[0].data ^= 1 << 30
+	if entry, ok := table.Probe(hash); ok {
+		t.Errorf("torn entry probed %+v", entry)
+	}
+}
+
+func TestTTReplacement(t *testing.T) {
+	const hash = 0xabcdef
+	move := newMove(int(E2), int(E4), Empty, flagDoublePush)
+	table := NewTranspositionTable(1)
+
+	table.Store(hash, move, 10, 8, boundLower)
+	table.Store(hash, NoMove, 20, 5, boundUpper)
+	if got, _ := table.Probe(hash); got.depth != 8 || got.score != 10 {
+		t.Errorf("shallower bound replaced a deeper entry of the same search: %+v", got)
+	}
+	table.Store(hash, NoMove, 30, 5, boundExact)
+	if got, _ := table.Probe(hash); got.depth != 5 || got.score != 30 || got.move != move {
+		t.Errorf("exact score did not replace the entry keeping its move: %+v", got)
+	}
+	table.NewSearch()
+	table.Store(hash, NoMove, 50, 2, boundUpper)
+	if got, _ := table.Probe(hash); got.depth != 2 || got.score != 50 || got.move != move {
+		t.Errorf("entry of an earlier search not replaced or lost its move: %+v", got)
+	}
+
+	// with a full bucket the shallowest entry goes, and entries of older searches first
+	table.Clear()
+	step := table.mask + 1 // hashes that differ by a multiple of this share a bucket
+	for i, depth := range []int{6, 3, 9, 7} {
+		table.Store(uint64(i+1)*step, NoMove, 0, depth, boundExact)
+	}
+	table.Store(5*step, NoMove, 0, 4, boundExact)
+	if _, ok := table.Probe(2 * step); ok {
+		t.Error("the shallowest entry was kept")
+	}
+	for _, i := range []uint64{1, 3, 4, 5} {
+		if _, ok := table.Probe(i * step); !ok {
+			t.Errorf("entry %d was replaced", i)
+		}
+	}
+	table.NewSearch()
+	table.Store(6*step, NoMove, 0, 1, boundExact)
+	if _, ok := table.Probe(5 * step); ok {
+		t.Error("the shallowest entry of the old search was kept")
+	}
+	table.NewSearch()
+	table.Store(7*step, NoMove, 0, 1, boundExact)
+	if _, ok := table.Probe(6 * step); !ok {
+		t.Error("an entry of the last search was replaced before the older deep entries")
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// engineName and engineAuthor are reported to GUIs
+const (
+	engineName   = "simple-go-chess"
+	engineAuthor = "HeinrichChristian"
+)
+
+// uciEngine holds the state of a UCI session
+type uciEngine struct {
+	pos Position
+
+	outMu sync.Mutex // info lines come from the search goroutine
+	out   io.Writer
+
+	moveOverhead time.Duration // Move Overhead option
+
+	// state of the running search, cancel is nil when idle
+	cancel  context.CancelFunc
+	done    chan struct{} // closed when the search has finished
+	release chan bool     // infinite and ponder searches wait for it: true sends bestmove, false discards it
+	params  goParams      // go command of the running search
+}
+
+// goParams holds the arguments of a UCI go command
+type goParams struct {
+	wtime, btime, winc, binc time.Duration
+	movesToGo                int
+	depth                    int
+	nodes                    uint64
+	moveTime                 time.Duration
+	infinite                 bool
+	ponder                   bool
+}
+
+// runUCI runs the Universal Chess Interface loop until quit or the end of input.
+// Commands read before calling runUCI can be passed in as first.
+func runUCI(scanner *bufio.Scanner, out io.Writer, first ...string) {
+	e := &uciEngine{pos: NewPosition(), out: out, moveOverhead: defaultMoveOverhead}
+	defer e.stopSearch()
+
+	for _, line := range first {
+		if !e.handle(line) {
+			return
+		}
+	}
+	for scanner.Scan() {
+		if !e.handle(scanner.Text()) {
+			return
+		}
+	}
+}
+
+// send writes one line to the GUI
+func (e *uciEngine) send(format string, args ...interface{}) {
+	e.outMu.Lock()
+	defer e.outMu.Unlock()
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+// handle executes one command line and returns false on quit
+func (e *uciEngine) handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+
+	switch fields[0] {
+	case "uci":
+		e.send("id name %s", engineName)
+		e.send("id author %s", engineAuthor)
+		e.send("option name Hash type spin default %d min 1 max %d", defaultHashMB, maxHashMB)
+		e.send("option name Clear Hash type button")
+		e.send("option name Move Overhead type spin default %d min 0 max %d",
+			defaultMoveOverhead.Milliseconds(), maxMoveOverhead.Milliseconds())
+		e.send("uciok")
+	case "isready":
+		e.send("readyok")
+	case "ucinewgame":
+		e.stopSearch()
+		e.pos = NewPosition()
+		transpositionTable.Clear()
+	case "position":
+		e.stopSearch()
+		if err := e.setPosition(fields[1:]); err != nil {
+			e.send("info string %v", err)
+		}
+	case "go":
+		e.stopSearch()
+		e.startSearch(parseGoParams(fields[1:]))
+	case "stop":
+		e.stopSearch()
+	case "ponderhit":
+		e.ponderHit()
+	case "setoption":
+		e.stopSearch()
+		e.setOption(fields[1:])
+	case "d":
+		var sb strings.Builder
+		e.pos.writeBoard(&sb, false)
+		e.send("%sFen: %s", sb.String(), e.pos.FEN())
+	case "eval":
+		// not part of UCI: the evaluation trace of the current position, for debugging
+		var sb strings.Builder
+		e.pos.writeEvalTrace(&sb)
+		e.send("%s", strings.TrimSuffix(sb.String(), "\n"))
+	case "quit":
+		return false
+	default:
+		e.send("info string unknown command %s", fields[0])
+	}
+	return true
+}
+
+// setPosition handles "position startpos|fen <fen> [moves m1 m2 ...]"
+func (e *uciEngine) setPosition(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("position: missing startpos or fen")
+	}
+
+	moves := len(args)
+	for i, a := range args {
+		if a == "moves" {
+			moves = i
+			break
+		}
+	}
+
+	var pos Position
+	switch args[0] {
+	case "startpos":
+		pos = NewPosition()
+	case "fen":
+		var err error
+		if pos, err = ParseFEN(strings.Join(args[1:moves], " ")); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("position: expected startpos or fen, got %q", args[0])
+	}
+
+	if moves < len(args) {
+		for _, s := range args[moves+1:] {
+			m, err := pos.ParseMove(s)
+			if err != nil {
+				return err
+			}
+			pos.MakeMove(m)
+		}
+	}
+	e.pos = pos
+	return nil
+}
+
+// setOption handles "setoption name <id> [value <x>]"
+func (e *uciEngine) setOption(args []string) {
+	name, value := splitOption(args)
+	switch strings.ToLower(name) {
+	case "hash":
+		mb, err := strconv.Atoi(value)
+		if err != nil {
+			e.send("info string invalid Hash value %q", value)
+			return
+		}
+		transpositionTable.Resize(mb)
+	case "clear hash":
+		transpositionTable.Clear()
+	case "move overhead":
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms < 0 || time.Duration(ms)*time.Millisecond > maxMoveOverhead {
+			e.send("info string invalid Move Overhead value %q", value)
+			return
+		}
+		e.moveOverhead = time.Duration(ms) * time.Millisecond
+	default:
+		e.send("info string unknown option %s", name)
+	}
+}
+
+// splitOption splits the arguments of setoption into name and value, both may contain spaces
+func splitOption(args []string) (string, string) {
+	var name, value []string
+	target := &name
+	for _, a := range args {
+		switch a {
+		case "name":
+			target = &name
+		case "value":
+			target = &value
+		default:
+			*target = append(*target, a)
+		}
+	}
+	return strings.Join(name, " "), strings.Join(value, " ")
+}
+
+// parseGoParams parses the arguments of a go command; unknown tokens are ignored
+func parseGoParams(args []string) goParams {
+	var g goParams
+	for i := 0; i < len(args); i++ {
+		next := func() int64 {
+			if i+1 >= len(args) {
+				return 0
+			}
+			i++
+			n, _ := strconv.ParseInt(args[i], 10, 64)
+			return n
+		}
+		switch args[i] {
+		case "wtime":
+			g.wtime = time.Duration(next()) * time.Millisecond
+		case "btime":
+			g.btime = time.Duration(next()) * time.Millisecond
+		case "winc":
+			g.winc = time.Duration(next()) * time.Millisecond
+		case "binc":
+			g.binc = time.Duration(next()) * time.Millisecond
+		case "movestogo":
+			g.movesToGo = int(next())
+		case "depth":
+			g.depth = int(next())
+		case "nodes":
+			g.nodes = uint64(next())
+		case "movetime":
+			g.moveTime = time.Duration(next()) * time.Millisecond
+		case "infinite":
+			g.infinite = true
+		case "ponder":
+			g.ponder = true
+		}
+	}
+	return g
+}
+
+// limits converts the go command into search limits for side, with the clock of that side
+func (g goParams) limits(side int, moveOverhead time.Duration) SearchLimits {
+	l := SearchLimits{
+		Depth:        g.depth,
+		Nodes:        g.nodes,
+		MoveTime:     g.moveTime,
+		Time:         g.wtime,
+		Increment:    g.winc,
+		MovesToGo:    g.movesToGo,
+		MoveOverhead: moveOverhead,
+	}
+	if side == Black {
+		l.Time, l.Increment = g.btime, g.binc
+	}
+	return l
+}
+
+// startSearch starts a search in the background; it sends bestmove when done.
+// A ponder search has no time limit, ponderhit restarts it with the clock running.
+func (e *uciEngine) startSearch(g goParams) {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	e.release = make(chan bool, 1)
+	e.params = g
+
+	// infinite and ponder searches must not send bestmove before stop or ponderhit
+	wait := g.infinite || g.ponder
+	limits := g.limits(e.pos.side, e.moveOverhead)
+	if wait {
+		limits = SearchLimits{Depth: g.depth, Nodes: g.nodes}
+	}
+
+	pos := e.pos.Clone()
+	done, release := e.done, e.release
+	go func() {
+		defer close(done)
+		result := Search(ctx, &pos, limits, e.sendInfo)
+		if wait && !<-release {
+			return
+		}
+		if len(result.PV) > 1 {
+			e.send("bestmove %s ponder %s", result.BestMove, result.PV[1])
+		} else {
+			e.send("bestmove %s", result.BestMove)
+		}
+	}()
+}
+
+// stopSearch stops a running search and waits until it has sent bestmove
+func (e *uciEngine) stopSearch() {
+	e.endSearch(true)
+}
+
+// ponderHit turns a ponder search into a normal search of its go command. The search is
+// restarted so the time manager starts counting now; the transposition table keeps the
+// work done while pondering.
+func (e *uciEngine) ponderHit() {
+	if e.cancel == nil || !e.params.ponder {
+		return
+	}
+	g := e.params
+	g.ponder = false
+	e.endSearch(false)
+	e.startSearch(g)
+}
+
+// endSearch stops a running search and waits for it; a waiting infinite or ponder search
+// sends its bestmove only if send is true
+func (e *uciEngine) endSearch(send bool) {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	select {
+	case e.release <- send:
+	default:
+	}
+	<-e.done
+	e.cancel = nil
+}
+
+// sendInfo reports a completed iteration as a UCI info line
+func (e *uciEngine) sendInfo(r SearchResult) {
+	score := fmt.Sprintf("cp %d", r.Score)
+	if n, ok := r.MateIn(); ok {
+		score = fmt.Sprintf("mate %d", n)
+	}
+	pv := make([]string, len(r.PV))
+	for i, m := range r.PV {
+		pv[i] = m.String()
+	}
+	e.send("info depth %d score %s nodes %d nps %d time %d pv %s",
+		r.Depth, score, r.Nodes, nodesPerSecond(r.Nodes, r.Time), r.Time.Milliseconds(), strings.Join(pv, " "))
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultXBoardMoveTime is the time per move when the GUI sent no time control with level,
+// st or time; without it the search would never stop
+const defaultXBoardMoveTime = 5 * time.Second
+
+// xboardEngine holds the state of a Chess Engine Communication Protocol (xboard) session
+type xboardEngine struct {
+	mu  sync.Mutex // guards everything below; the search goroutine plays its move under it
+	pos Position
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	engineSide int  // side the engine plays, Empty in force mode
+	post       bool // send thinking output
+
+	// time control from level, st and sd
+	movesPerSession int
+	base, inc       time.Duration
+	fixedTime       time.Duration
+	maxDepth        int
+	engineTime      time.Duration // remaining clock times from time and otim
+	opponentTime    time.Duration
+
+	// state of the running search, cancel is nil when idle
+	cancel   context.CancelFunc
+	done     chan struct{}
+	searchID int // incremented to make a running search discard its result
+}
+
+// runXBoard runs the xboard protocol loop until quit or the end of input.
+func runXBoard(scanner *bufio.Scanner, out io.Writer) {
+	e := &xboardEngine{pos: NewPosition(), out: out, engineSide: Black}
+	for scanner.Scan() {
+		e.mu.Lock()
+		ok := e.handle(scanner.Text())
+		e.mu.Unlock()
+		if !ok {
+			break
+		}
+	}
+	e.mu.Lock()
+	e.stopThinking(true)
+	e.mu.Unlock()
+}
+
+// send writes one line to the GUI
+func (e *xboardEngine) send(format string, args ...interface{}) {
+	e.outMu.Lock()
+	defer e.outMu.Unlock()
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+// handle executes one command line with e.mu held and returns false on quit
+func (e *xboardEngine) handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+	args := fields[1:]
+
+	switch fields[0] {
+	case "xboard", "accepted", "rejected", "random", "hard", "easy", "computer", "name", "rating":
+		// nothing to do
+	case "protover":
+		e.send("feature done=0")
+		e.send("feature myname=\"%s\" ping=1 setboard=1 usermove=1 playother=1 memory=1 colors=0 sigint=0 sigterm=0 analyze=0", engineName)
+		e.send("feature done=1")
+	case "ping":
+		e.send("pong %s", strings.Join(args, " "))
+	case "new":
+		e.stopThinking(true)
+		e.pos = NewPosition()
+		e.engineSide = Black
+		e.maxDepth = 0
+		transpositionTable.Clear()
+	case "memory":
+		if len(args) > 0 {
+			e.stopThinking(true)
+			mb, _ := strconv.Atoi(args[0])
+			transpositionTable.Resize(mb)
+		}
+	case "force":
+		e.stopThinking(true)
+		e.engineSide = Empty
+	case "go":
+		e.stopThinking(true)
+		e.engineSide = e.pos.side
+		e.think()
+	case "playother":
+		e.stopThinking(true)
+		e.engineSide = e.pos.side ^ 1
+	case "usermove":
+		if len(args) == 0 {
+			e.send("Error (missing move): usermove")
+			return true
+		}
+		e.userMove(args[0])
+	case "?":
+		e.stopThinking(false)
+	case "level":
+		e.setLevel(args)
+	case "st":
+		if len(args) > 0 {
+			n, _ := strconv.Atoi(args[0])
+			e.fixedTime = time.Duration(n) * time.Second
+		}
+	case "sd":
+		if len(args) > 0 {
+			e.maxDepth, _ = strconv.Atoi(args[0])
+		}
+	case "time", "otim":
+		if len(args) > 0 {
+			n, _ := strconv.Atoi(args[0])
+			t := time.Duration(n) * 10 * time.Millisecond
+			if fields[0] == "time" {
+				e.engineTime = t
+			} else {
+				e.opponentTime = t
+			}
+		}
+	case "undo":
+		e.stopThinking(true)
+		e.takeBack(1)
+	case "remove":
+		e.stopThinking(true)
+		e.takeBack(2)
+	case "setboard":
+		e.stopThinking(true)
+		pos, err := ParseFEN(strings.Join(args, " "))
+		if err != nil {
+			e.send("tellusererror Illegal position: %v", err)
+			return true
+		}
+		e.pos = pos
+	case "post":
+		e.post = true
+	case "nopost":
+		e.post = false
+	case "result":
+		e.stopThinking(true)
+		e.engineSide = Empty
+	case "quit":
+		return false
+	default:
+		e.send("Error (unknown command): %s", fields[0])
+	}
+	return true
+}
+
+// userMove plays the opponent's move and lets the engine answer if it is its turn
+func (e *xboardEngine) userMove(s string) {
+	e.stopThinking(true)
+	m, err := e.pos.ParseMove(s)
+	if err != nil {
+		e.send("Illegal move: %s", s)
+		return
+	}
+	e.pos.MakeMove(m)
+	if e.reportResult() {
+		return
+	}
+	if e.engineSide == e.pos.side {
+		e.think()
+	}
+}
+
+// takeBack retracts n half-moves, as far as the move history reaches
+func (e *xboardEngine) takeBack(n int) {
+	for i := 0; i < n && len(e.pos.undo) > 0; i++ {
+		e.pos.UnmakeMove()
+	}
+}
+
+// setLevel handles "level MPS BASE INC", BASE is minutes or minutes:seconds
+func (e *xboardEngine) setLevel(args []string) {
+	if len(args) < 3 {
+		e.send("Error (missing arguments): level")
+		return
+	}
+	e.movesPerSession, _ = strconv.Atoi(args[0])
+
+	minutes, seconds := args[1], "0"
+	if i := strings.IndexByte(minutes, ':'); i >= 0 {
+		minutes, seconds = minutes[:i], minutes[i+1:]
+	}
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	e.base = time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+
+	inc, _ := strconv.ParseFloat(args[2], 64)
+	e.inc = time.Duration(inc * float64(time.Second))
+	e.fixedTime = 0
+}
+
+// goParams translates the xboard time control into the parameters of a UCI go command,
+// so both protocols share the same time allocation
+func (e *xboardEngine) goParams() goParams {
+	g := goParams{depth: e.maxDepth, moveTime: e.fixedTime}
+	if g.moveTime > 0 {
+		return g
+	}
+
+	engineTime, opponentTime := e.engineTime, e.opponentTime
+	if engineTime <= 0 {
+		engineTime, opponentTime = e.base, e.base
+	}
+	if engineTime <= 0 && e.inc <= 0 {
+		g.moveTime = defaultXBoardMoveTime
+		return g
+	}
+	if e.pos.side == White {
+		g.wtime, g.btime, g.winc, g.binc = engineTime, opponentTime, e.inc, e.inc
+	} else {
+		g.btime, g.wtime, g.binc, g.winc = engineTime, opponentTime, e.inc, e.inc
+	}
+	if e.movesPerSession > 0 {
+		g.movesToGo = e.movesPerSession - (e.pos.fullmoveNumber-1)%e.movesPerSession
+	}
+	return g
+}
+
+// think starts a search for the engine's move in the background
+func (e *xboardEngine) think() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	e.searchID++
+
+	pos := e.pos.Clone()
+	limits := e.goParams().limits(e.pos.side, defaultMoveOverhead)
+	id, done := e.searchID, e.done
+	// post is guarded by e.mu, so decide on the thinking output here, not in the search goroutine
+	var info func(SearchResult)
+	if e.post {
+		info = e.sendThinking
+	}
+	go func() {
+		defer close(done)
+		result := Search(ctx, &pos, limits, info)
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if id != e.searchID {
+			return
+		}
+		e.cancel = nil
+		if result.BestMove == NoMove {
+			return
+		}
+		e.pos.MakeMove(result.BestMove)
+		e.send("move %s", result.BestMove)
+		e.reportResult()
+	}()
+}
+
+// stopThinking stops a running search. With discard the search result is thrown away,
+// otherwise the engine plays the best move found so far. Called with e.mu held.
+func (e *xboardEngine) stopThinking(discard bool) {
+	if e.cancel == nil {
+		return
+	}
+	if discard {
+		e.searchID++
+	}
+	e.cancel()
+	e.cancel = nil
+	done := e.done
+
+	// the search goroutine needs the lock to play its move
+	e.mu.Unlock()
+	<-done
+	e.mu.Lock()
+}
+
+// sendThinking reports a completed iteration in the xboard post format:
+// depth, score in centipawns, time in centiseconds, nodes and the principal variation
+func (e *xboardEngine) sendThinking(r SearchResult) {
+	score := r.Score
+	if n, ok := r.MateIn(); ok {
+		// xboard shows 100000+N as mate in N
+		if n > 0 {
+			score = 100000 + n
+		} else {
+			score = -100000 + n
+		}
+	}
+	pv := make([]string, len(r.PV))
+	for i, m := range r.PV {
+		pv[i] = m.String()
+	}
+	e.send("%d %d %d %d %s", r.Depth, score, r.Time.Milliseconds()/10, r.Nodes, strings.Join(pv, " "))
+}
+
+// reportResult sends the result when the game is over, or a draw can be claimed,
+// and reports whether it is
+func (e *xboardEngine) reportResult() bool {
+	status := e.pos.GameStatus()
+	if !status.Over() {
+		return false
+	}
+	e.send("%s", status)
+	return true
+}
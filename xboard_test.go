@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestXBoardPlaysMove(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go func() {
+		runXBoard(bufio.NewScanner(inR), outW)
+		outW.Close()
+	}()
+
+	// the engine answers while it reads, so feed it from another goroutine
+	quit := make(chan struct{})
+	go func() {
+		io.WriteString(inW, "xboard\nprotover 2\nnew\nsd 2\nusermove e2e4\n")
+		<-quit
+		io.WriteString(inW, "quit\n")
+		inW.Close()
+	}()
+
+	var move string
+	lines := bufio.NewScanner(outR)
+	for move == "" && lines.Scan() {
+		if strings.HasPrefix(lines.Text(), "move ") {
+			move = strings.TrimPrefix(lines.Text(), "move ")
+		}
+	}
+	close(quit)
+	for lines.Scan() {
+	}
+
+	pos := NewPosition()
+	if m, err := pos.ParseMove("e2e4"); err == nil {
+		pos.MakeMove(m)
+	}
+	if _, err := pos.ParseMove(move); err != nil {
+		t.Errorf("engine answered %q: %v", move, err)
+	}
+}
+
+func TestXBoardGoParams(t *testing.T) {
+	tests := []struct {
+		name string
+		e    *xboardEngine
+		want goParams
+	}{
+		{"no time control", &xboardEngine{}, goParams{moveTime: defaultXBoardMoveTime}},
+		{"depth only", &xboardEngine{maxDepth: 2}, goParams{depth: 2, moveTime: defaultXBoardMoveTime}},
+		{"st", &xboardEngine{fixedTime: 3 * time.Second}, goParams{moveTime: 3 * time.Second}},
+		{"level", &xboardEngine{movesPerSession: 40, base: 5 * time.Minute, inc: time.Second},
+			goParams{wtime: 5 * time.Minute, btime: 5 * time.Minute, winc: time.Second, binc: time.Second, movesToGo: 40}},
+		{"time and otim", &xboardEngine{base: time.Minute, engineTime: 20 * time.Second, opponentTime: 30 * time.Second},
+			goParams{wtime: 20 * time.Second, btime: 30 * time.Second}},
+	}
+	for _, tt := range tests {
+		tt.e.pos = NewPosition()
+		if got := tt.e.goParams(); got != tt.want {
+			t.Errorf("%s: goParams = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
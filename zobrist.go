@@ -0,0 +1,72 @@
+package main
+
+// Zobrist keys: a position's hash is the XOR of the keys of everything on the board
+var zobristPieces [2][6][64]uint64 // per color, piece type and square
+var zobristSide uint64             // XOR-ed in when Black is to move
+var zobristCastling [16]uint64     // per combination of castling rights
+var zobristEnPassant [8]uint64     // per file of the en-passant square
+
+// initZobrist fills the Zobrist keys from a fixed seed, so hashes are stable between runs
+func initZobrist() {
+	rng := uint64(0x2545f4914f6cdd1d)
+	for color := White; color <= Black; color++ {
+		for piece := Pawn; piece <= King; piece++ {
+			for sq := 0; sq < 64; sq++ {
+				zobristPieces[color][piece][sq] = xorshift(&rng)
+			}
+		}
+	}
+	zobristSide = xorshift(&rng)
+	for i := range zobristCastling {
+		zobristCastling[i] = xorshift(&rng)
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = xorshift(&rng)
+	}
+}
+
+// Hash returns the Zobrist key of the position.
+func (p *Position) Hash() uint64 {
+	return p.hash
+}
+
+// ComputeHash computes the Zobrist key from scratch. The key kept in the position
+// is updated incrementally; this is the reference it is checked against.
+func (p *Position) ComputeHash() uint64 {
+	var h uint64
+	for sq := 0; sq < 64; sq++ {
+		if p.pieces[sq] != Empty {
+			h ^= zobristPieces[p.colors[sq]][p.pieces[sq]][sq]
+		}
+	}
+	if p.side == Black {
+		h ^= zobristSide
+	}
+	h ^= zobristCastling[p.castling]
+	if p.epSquare != noSquare {
+		h ^= zobristEnPassant[squareFile[p.epSquare]]
+	}
+	return h
+}
+
+// computePawnHash computes the Zobrist key of the pawns from scratch
+func (p *Position) computePawnHash() uint64 {
+	var h uint64
+	for color := White; color <= Black; color++ {
+		for b := p.pieceBB[color][Pawn]; b != 0; {
+			h ^= zobristPieces[color][Pawn][popLSB(&b)]
+		}
+	}
+	return h
+}
+
+// checkHash panics when an incremental key differs from a freshly computed one.
+// It is only called in builds with the debug tag.
+func (p *Position) checkHash(where string) {
+	if h := p.ComputeHash(); h != p.hash {
+		panic(where + ": incremental hash does not match ComputeHash for " + p.FEN())
+	}
+	if h := p.computePawnHash(); h != p.pawnHash {
+		panic(where + ": incremental pawn hash does not match computePawnHash for " + p.FEN())
+	}
+}